@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+)
+
+// exporter writes messages to an output format
+type exporter interface {
+	Write(msg itch.ItchMessage) error
+	Close() error
+}
+
+// runExport implements the export subcommand, which converts an ITCH file to another format
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	filePath := flags.String("file", "", "Path to ITCH file")
+	format := flags.String("format", "csv", "Output format: csv")
+	out := flags.String("out", ".", "Directory to write the output files to")
+	prefixed := flags.Bool("prefixed", true, "Whether each ITCH message is prefixed by a two byte length field")
+	types := flags.String("types", "", "Message types to export, such as AFE. Defaults to every type")
+	symbols := flags.String("symbols", "", "Comma separated stock symbols to export. Defaults to every stock")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return fmt.Errorf("export: %w", err)
+	}
+
+	if *filePath == "" {
+		return errors.New("export: -file is required")
+	}
+
+	config := itch.Configuration{
+		MessageTypes:        []byte(*types),
+		ReadBufferSize:      1 << 20,
+		LengthFieldPrefixed: *prefixed,
+	}
+	if *symbols != "" {
+		config.Symbols = strings.Split(*symbols, ",")
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	var e exporter
+	switch *format {
+	case "csv":
+		e = itch.NewCSVFileExporter(*out)
+	case "parquet":
+		// Parquet support lives in its own module so the parser doesn't depend on Arrow
+		return errors.New("export: parquet is written by the itch-parquet command in the itch/5.0/columnar module")
+	default:
+		return fmt.Errorf("export: unknown format %q", *format)
+	}
+
+	decoder, err := itch.OpenFile(*filePath, config)
+	if err != nil {
+		return err
+	}
+	defer decoder.Close()
+
+	log.Printf("Exporting %s as %s to %s", *filePath, *format, *out)
+
+	count := 0
+	for message, err := range decoder.All() {
+		if err != nil {
+			// Messages that fail to parse are skipped, an error that stopped the decoder is returned below
+			if decoder.Err() != nil {
+				break
+			}
+			log.Print(err)
+			continue
+		}
+
+		if err := e.Write(message); err != nil {
+			return errors.Join(err, e.Close())
+		}
+		count++
+	}
+
+	if err := decoder.Err(); err != nil {
+		return errors.Join(err, e.Close())
+	}
+
+	if err := e.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("Exported %d messages", count)
+
+	return nil
+}
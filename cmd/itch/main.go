@@ -7,14 +7,25 @@ package main
 import (
 	"flag"
 	"log"
+	"os"
 
 	itch "github.com/markwinter/go-finproto/itch/5.0"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	filePath := flag.String("file", "", "Path to ITCH file")
 	flag.Parse()
 
+	// state is populated with the Stock Directory and Market Participant Position messages in the file
+	state := itch.NewFeedState()
+
 	config := itch.Configuration{
 		MessageTypes: []byte{
 			itch.MESSAGE_ORDER_ADD_ATTRIBUTED,
@@ -22,6 +33,7 @@ func main() {
 		MaxMessages:         0,
 		ReadBufferSize:      itch.OneGB,
 		LengthFieldPrefixed: true, // The sample file from NASDAQ FTP has a two-byte length field prefixed to each ITCH message
+		FeedState:           state,
 	}
 
 	log.Printf("Parsing file: %s", *filePath)
@@ -54,7 +66,7 @@ func main() {
 	*/
 
 	// Get participant positions for Goldman Sachs
-	//goldmanSachs := itch.MarketParticipants["GSCO"]
+	//goldmanSachs := state.Participants("GSCO")
 	//fmt.Print(goldmanSachs[0]) // Print their first position
 
 	// [Market Participant Position]
@@ -68,7 +80,8 @@ func main() {
 	// State: Active
 
 	// Print the related stock for the position
-	//fmt.Print(itch.Directory[goldmanSachs[0].StockLocate])
+	//stock, _ := state.StockDirectory(goldmanSachs[0].StockLocate)
+	//fmt.Print(stock)
 
 	// [Stock Directory]
 	// Stock Locate: 1176
@@ -89,9 +102,10 @@ func main() {
 	// ETP Leverage Factor: 0
 	// Inverse Indicator: false
 
-	// Alternatively get stock by symbol using itch.StockMap
-	//stockLocate := itch.StockMap["AAPL"]
-	//fmt.Print(itch.Directory[stockLocate])
+	// Alternatively get stock by symbol using state.Locate
+	//stockLocate, _ := state.Locate("AAPL")
+	//stock, _ = state.StockDirectory(stockLocate)
+	//fmt.Print(stock)
 
 	// [Stock Directory]
 	// Stock Locate: 13
@@ -0,0 +1,315 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+import (
+	"fmt"
+	"time"
+
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+// BBO is the best bid and offer of a stock along with the ITCH message that last changed it.
+// A side with no resting orders has a zero price and zero shares
+type BBO struct {
+	BidPrice       udecimal.Decimal // Price (4)
+	AskPrice       udecimal.Decimal // Price (4)
+	Timestamp      time.Duration
+	BidShares      uint64
+	AskShares      uint64
+	StockLocate    uint16
+	TrackingNumber uint16
+}
+
+// BBOTracker tracks the top of book for every stock and calls a callback only when a stock's best bid
+// or ask price or size changes.
+//
+// It is lighter than an OrderBook: orders are not kept in price-time priority and no level updates or
+// depth snapshots are produced. The tracker keeps each order's price, side and remaining shares to resolve
+// executions and cancels, plus the total shares at each price so the next best price can be found when the
+// best level is removed. Finding it scans that side's prices, which only happens when the best level empties.
+//
+// BBOTracker implements itch.Handler so it can be passed straight to itch.DecodeTo.
+type BBOTracker struct {
+	itch.NopHandler
+
+	orders   map[uint64]bboOrder
+	stocks   map[uint16]*bboStock
+	bbos     map[uint16]BBO
+	callback func(BBO)
+}
+
+type bboOrder struct {
+	price       udecimal.Decimal
+	ticks       int64
+	shares      uint32
+	stockLocate uint16
+	side        itch.OrderIndicator
+}
+
+type bboStock struct {
+	bids bboSide
+	asks bboSide
+}
+
+// bboSide holds the total shares at each price on one side of a stock's book and caches the best price
+type bboSide struct {
+	side   itch.OrderIndicator
+	levels map[int64]bboLevel
+	best   int64
+}
+
+type bboLevel struct {
+	price  udecimal.Decimal
+	shares uint64
+}
+
+// NewBBOTracker creates a BBOTracker that calls callback every time a stock's BBO changes. callback may be nil
+func NewBBOTracker(callback func(BBO)) *BBOTracker {
+	return &BBOTracker{
+		orders:   make(map[uint64]bboOrder),
+		stocks:   make(map[uint16]*bboStock),
+		bbos:     make(map[uint16]BBO),
+		callback: callback,
+	}
+}
+
+// Apply updates the tracker with a single ITCH message. Message types that do not affect the book are ignored
+func (t *BBOTracker) Apply(msg itch.ItchMessage) error {
+	var (
+		locate    uint16
+		tracking  uint16
+		timestamp time.Duration
+		err       error
+	)
+
+	switch m := msg.(type) {
+	case itch.OrderAdd:
+		locate, tracking, timestamp = m.StockLocate, m.TrackingNumber, m.Timestamp
+		err = t.add(m.StockLocate, m.Reference, m.OrderIndicator, m.Price, m.Shares)
+	case itch.OrderAddAttributed:
+		locate, tracking, timestamp = m.StockLocate, m.TrackingNumber, m.Timestamp
+		err = t.add(m.StockLocate, m.Reference, m.OrderIndicator, m.Price, m.Shares)
+	case itch.OrderExecuted:
+		locate, tracking, timestamp = m.StockLocate, m.TrackingNumber, m.Timestamp
+		err = t.reduce(m.Reference, m.Shares)
+	case itch.OrderExecutedPrice:
+		locate, tracking, timestamp = m.StockLocate, m.TrackingNumber, m.Timestamp
+		err = t.reduce(m.Reference, m.Shares)
+	case itch.OrderCancel:
+		locate, tracking, timestamp = m.StockLocate, m.TrackingNumber, m.Timestamp
+		err = t.reduce(m.Reference, m.Shares)
+	case itch.OrderDelete:
+		locate, tracking, timestamp = m.StockLocate, m.TrackingNumber, m.Timestamp
+		err = t.delete(m.Reference)
+	case itch.OrderReplace:
+		locate, tracking, timestamp = m.StockLocate, m.TrackingNumber, m.Timestamp
+		err = t.replace(m.OriginalReference, m.NewReference, m.Price, m.Shares)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	t.update(locate, timestamp, tracking)
+
+	return nil
+}
+
+func (t *BBOTracker) OnOrderAdd(m itch.OrderAdd) {
+	_ = t.Apply(m)
+}
+
+func (t *BBOTracker) OnOrderAddAttributed(m itch.OrderAddAttributed) {
+	_ = t.Apply(m)
+}
+
+func (t *BBOTracker) OnOrderExecuted(m itch.OrderExecuted) {
+	_ = t.Apply(m)
+}
+
+func (t *BBOTracker) OnOrderExecutedPrice(m itch.OrderExecutedPrice) {
+	_ = t.Apply(m)
+}
+
+func (t *BBOTracker) OnOrderCancel(m itch.OrderCancel) {
+	_ = t.Apply(m)
+}
+
+func (t *BBOTracker) OnOrderDelete(m itch.OrderDelete) {
+	_ = t.Apply(m)
+}
+
+func (t *BBOTracker) OnOrderReplace(m itch.OrderReplace) {
+	_ = t.Apply(m)
+}
+
+// BBO returns the current best bid and offer of a stock
+func (t *BBOTracker) BBO(stockLocate uint16) (BBO, bool) {
+	bbo, ok := t.bbos[stockLocate]
+	return bbo, ok
+}
+
+func (t *BBOTracker) add(locate uint16, reference uint64, side itch.OrderIndicator, price udecimal.Decimal, shares uint32) error {
+	if _, ok := t.orders[reference]; ok {
+		return fmt.Errorf("%w %d", ErrDuplicateOrder, reference)
+	}
+	if side != itch.ORDER_INDICATOR_BUY && side != itch.ORDER_INDICATOR_SELL {
+		return fmt.Errorf("invalid order indicator=%v for order %d", side, reference)
+	}
+
+	s, ok := t.stocks[locate]
+	if !ok {
+		s = &bboStock{
+			bids: bboSide{side: itch.ORDER_INDICATOR_BUY, levels: make(map[int64]bboLevel)},
+			asks: bboSide{side: itch.ORDER_INDICATOR_SELL, levels: make(map[int64]bboLevel)},
+		}
+		t.stocks[locate] = s
+	}
+
+	o := bboOrder{
+		price:       price,
+		ticks:       priceTicks(price),
+		shares:      shares,
+		stockLocate: locate,
+		side:        side,
+	}
+
+	s.side(side).add(o.ticks, o.price, uint64(shares))
+	t.orders[reference] = o
+
+	return nil
+}
+
+// reduce removes shares from an order following an execution or partial cancel. The order is forgotten
+// once it has no shares remaining
+func (t *BBOTracker) reduce(reference uint64, shares uint32) error {
+	o, ok := t.orders[reference]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownOrder, reference)
+	}
+
+	shares = min(shares, o.shares)
+	t.stocks[o.stockLocate].side(o.side).remove(o.ticks, uint64(shares))
+
+	if shares == o.shares {
+		delete(t.orders, reference)
+		return nil
+	}
+
+	o.shares -= shares
+	t.orders[reference] = o
+
+	return nil
+}
+
+func (t *BBOTracker) delete(reference uint64) error {
+	o, ok := t.orders[reference]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownOrder, reference)
+	}
+
+	return t.reduce(reference, o.shares)
+}
+
+func (t *BBOTracker) replace(original, reference uint64, price udecimal.Decimal, shares uint32) error {
+	o, ok := t.orders[original]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownOrder, original)
+	}
+	if _, ok := t.orders[reference]; ok {
+		return fmt.Errorf("%w %d", ErrDuplicateOrder, reference)
+	}
+
+	if err := t.delete(original); err != nil {
+		return err
+	}
+
+	return t.add(o.stockLocate, reference, o.side, price, shares)
+}
+
+func (t *BBOTracker) update(locate uint16, timestamp time.Duration, tracking uint16) {
+	bbo := BBO{
+		Timestamp:      timestamp,
+		StockLocate:    locate,
+		TrackingNumber: tracking,
+	}
+
+	if s, ok := t.stocks[locate]; ok {
+		if best, ok := s.bids.levels[s.bids.best]; ok {
+			bbo.BidPrice, bbo.BidShares = best.price, best.shares
+		}
+		if best, ok := s.asks.levels[s.asks.best]; ok {
+			bbo.AskPrice, bbo.AskShares = best.price, best.shares
+		}
+	}
+
+	previous, ok := t.bbos[locate]
+	if ok && previous.BidShares == bbo.BidShares && previous.AskShares == bbo.AskShares &&
+		previous.BidPrice.Equal(bbo.BidPrice) && previous.AskPrice.Equal(bbo.AskPrice) {
+		return
+	}
+
+	t.bbos[locate] = bbo
+
+	if t.callback != nil {
+		t.callback(bbo)
+	}
+}
+
+func (s *bboStock) side(side itch.OrderIndicator) *bboSide {
+	if side == itch.ORDER_INDICATOR_BUY {
+		return &s.bids
+	}
+
+	return &s.asks
+}
+
+// better reports whether price a is better than price b for this side of the book
+func (s *bboSide) better(a, b int64) bool {
+	if s.side == itch.ORDER_INDICATOR_BUY {
+		return a > b
+	}
+
+	return a < b
+}
+
+func (s *bboSide) add(ticks int64, price udecimal.Decimal, shares uint64) {
+	lvl, ok := s.levels[ticks]
+	if !ok {
+		lvl.price = price
+	}
+	lvl.shares += shares
+	s.levels[ticks] = lvl
+
+	if len(s.levels) == 1 || s.better(ticks, s.best) {
+		s.best = ticks
+	}
+}
+
+func (s *bboSide) remove(ticks int64, shares uint64) {
+	lvl := s.levels[ticks]
+	lvl.shares -= shares
+	if lvl.shares > 0 {
+		s.levels[ticks] = lvl
+		return
+	}
+
+	delete(s.levels, ticks)
+
+	if ticks != s.best {
+		return
+	}
+
+	first := true
+	for t := range s.levels {
+		if first || s.better(t, s.best) {
+			s.best, first = t, false
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+func TestBBOTracker(t *testing.T) {
+	changes := []BBO{}
+	tracker := NewBBOTracker(func(bbo BBO) {
+		changes = append(changes, bbo)
+	})
+
+	messages := []itch.ItchMessage{
+		addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		addOrder(2, itch.ORDER_INDICATOR_SELL, "10.05", 100),
+		// Behind the best bid so no change
+		addOrder(3, itch.ORDER_INDICATOR_BUY, "9.99", 100),
+		itch.OrderCancel{StockLocate: 1, Timestamp: time.Hour, TrackingNumber: 3, Reference: 3, Shares: 50},
+		// Joins the best bid so the size changes
+		addOrder(4, itch.ORDER_INDICATOR_BUY, "10.00", 200),
+		itch.OrderDelete{StockLocate: 1, Timestamp: 2 * time.Hour, Reference: 2},
+	}
+	for _, m := range messages {
+		if err := tracker.Apply(m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	want := []BBO{
+		{BidPrice: udecimal.MustParse("10.00"), BidShares: 100, Timestamp: time.Second, StockLocate: 1},
+		{BidPrice: udecimal.MustParse("10.00"), BidShares: 100, AskPrice: udecimal.MustParse("10.05"), AskShares: 100, Timestamp: 2 * time.Second, StockLocate: 1},
+		{BidPrice: udecimal.MustParse("10.00"), BidShares: 300, AskPrice: udecimal.MustParse("10.05"), AskShares: 100, Timestamp: 4 * time.Second, StockLocate: 1},
+		{BidPrice: udecimal.MustParse("10.00"), BidShares: 300, Timestamp: 2 * time.Hour, StockLocate: 1},
+	}
+
+	if !cmp.Equal(changes, want) {
+		t.Errorf("%v", cmp.Diff(want, changes))
+	}
+
+	got, ok := tracker.BBO(1)
+	if !ok || !cmp.Equal(got, want[len(want)-1]) {
+		t.Errorf("BBO() = %v, %v, want %v", got, ok, want[len(want)-1])
+	}
+}
+
+func TestBBOTracker_NextBest(t *testing.T) {
+	tracker := NewBBOTracker(nil)
+
+	messages := []itch.ItchMessage{
+		addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		addOrder(2, itch.ORDER_INDICATOR_BUY, "9.98", 300),
+		addOrder(3, itch.ORDER_INDICATOR_BUY, "9.99", 200),
+		addOrder(4, itch.ORDER_INDICATOR_SELL, "10.02", 100),
+		addOrder(5, itch.ORDER_INDICATOR_SELL, "10.01", 100),
+		addOrder(6, itch.ORDER_INDICATOR_SELL, "10.01", 50),
+		// Empties the best bid so 9.99 becomes the best
+		itch.OrderExecuted{StockLocate: 1, Timestamp: time.Hour, Reference: 1, Shares: 100},
+		// Moves the only order at 9.99 behind 9.98
+		itch.OrderReplace{StockLocate: 1, Timestamp: 2 * time.Hour, OriginalReference: 3, NewReference: 7, Shares: 200, Price: udecimal.MustParse("9.97")},
+		// Partially empties the best ask
+		itch.OrderCancel{StockLocate: 1, Timestamp: 3 * time.Hour, Reference: 5, Shares: 100},
+	}
+	for _, m := range messages {
+		if err := tracker.Apply(m); err != nil {
+			t.Fatalf("Apply(%T) error = %v", m, err)
+		}
+	}
+
+	want := BBO{
+		BidPrice:    udecimal.MustParse("9.98"),
+		BidShares:   300,
+		AskPrice:    udecimal.MustParse("10.01"),
+		AskShares:   50,
+		Timestamp:   3 * time.Hour,
+		StockLocate: 1,
+	}
+	if got, ok := tracker.BBO(1); !ok || !cmp.Equal(got, want) {
+		t.Errorf("BBO() = %v, %v, want %v", got, ok, want)
+	}
+
+	if err := tracker.Apply(itch.OrderDelete{StockLocate: 1, Reference: 1}); !errors.Is(err, ErrUnknownOrder) {
+		t.Errorf("Apply(OrderDelete) error = %v, want %v", err, ErrUnknownOrder)
+	}
+	if err := tracker.Apply(addOrder(2, itch.ORDER_INDICATOR_BUY, "9.98", 1)); !errors.Is(err, ErrDuplicateOrder) {
+		t.Errorf("Apply(OrderAdd) error = %v, want %v", err, ErrDuplicateOrder)
+	}
+}
@@ -0,0 +1,382 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+// Package book reconstructs order books from Nasdaq ITCH 5.0 order messages
+package book
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+var (
+	ErrUnknownOrder   = errors.New("unknown order reference")
+	ErrDuplicateOrder = errors.New("duplicate order reference")
+)
+
+// Order is a resting order on the book
+type Order struct {
+	Price       udecimal.Decimal // Price (4)
+	Attribution string
+	// Timestamp is when the order gained its current priority, i.e. when it was added or replaced
+	Timestamp   time.Duration
+	Reference   uint64
+	Shares      uint32
+	StockLocate uint16
+	Side        itch.OrderIndicator
+
+	level      *level
+	prev, next *Order
+}
+
+// level is a single price on one side of the book. Orders are kept in a FIFO list so the head
+// of the list has time priority
+type level struct {
+	price  udecimal.Decimal
+	ticks  int64
+	shares uint64
+	count  int
+
+	head, tail *Order
+}
+
+// ladder holds the price levels for one side of a stock's book. Levels are sorted worst to best so the
+// best price is at the end of the slice, where most changes happen
+type ladder struct {
+	side    itch.OrderIndicator
+	levels  []*level
+	byTicks map[int64]*level
+}
+
+type stockBook struct {
+	bids ladder
+	asks ladder
+}
+
+// OrderBook maintains full depth (L3) order books for every stock locate in a feed. It implements
+// itch.Handler so it can be passed straight to itch.DecodeTo.
+//
+// The Handler methods ignore messages that refer to unknown orders, which is normal when joining
+// a feed part way through the day. Use Apply to observe these errors instead.
+type OrderBook struct {
+	itch.NopHandler
+
+	orders map[uint64]*Order
+	stocks map[uint16]*stockBook
+
+	levelCallback func(LevelUpdate)
+}
+
+// New creates an empty OrderBook. The default behaviour can be configured using Options passed in as parameters
+func New(opts ...Option) *OrderBook {
+	b := &OrderBook{
+		orders: make(map[uint64]*Order),
+		stocks: make(map[uint16]*stockBook),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b
+}
+
+// Apply updates the book with a single ITCH message. Message types that do not affect the book are ignored
+func (b *OrderBook) Apply(msg itch.ItchMessage) error {
+	switch m := msg.(type) {
+	case itch.OrderAdd:
+		return b.add(m.StockLocate, m.Reference, m.OrderIndicator, m.Price, m.Shares, m.Timestamp, m.TrackingNumber, "")
+	case itch.OrderAddAttributed:
+		return b.add(m.StockLocate, m.Reference, m.OrderIndicator, m.Price, m.Shares, m.Timestamp, m.TrackingNumber, m.Attribution)
+	case itch.OrderExecuted:
+		return b.reduce(m.Reference, m.Shares, m.Timestamp, m.TrackingNumber)
+	case itch.OrderExecutedPrice:
+		return b.reduce(m.Reference, m.Shares, m.Timestamp, m.TrackingNumber)
+	case itch.OrderCancel:
+		return b.reduce(m.Reference, m.Shares, m.Timestamp, m.TrackingNumber)
+	case itch.OrderDelete:
+		return b.delete(m.Reference, m.Timestamp, m.TrackingNumber)
+	case itch.OrderReplace:
+		return b.replace(m.OriginalReference, m.NewReference, m.Price, m.Shares, m.Timestamp, m.TrackingNumber)
+	}
+
+	return nil
+}
+
+func (b *OrderBook) OnOrderAdd(m itch.OrderAdd) {
+	_ = b.Apply(m)
+}
+
+func (b *OrderBook) OnOrderAddAttributed(m itch.OrderAddAttributed) {
+	_ = b.Apply(m)
+}
+
+func (b *OrderBook) OnOrderExecuted(m itch.OrderExecuted) {
+	_ = b.Apply(m)
+}
+
+func (b *OrderBook) OnOrderExecutedPrice(m itch.OrderExecutedPrice) {
+	_ = b.Apply(m)
+}
+
+func (b *OrderBook) OnOrderCancel(m itch.OrderCancel) {
+	_ = b.Apply(m)
+}
+
+func (b *OrderBook) OnOrderDelete(m itch.OrderDelete) {
+	_ = b.Apply(m)
+}
+
+func (b *OrderBook) OnOrderReplace(m itch.OrderReplace) {
+	_ = b.Apply(m)
+}
+
+// Order returns a copy of the resting order with the given reference
+func (b *OrderBook) Order(reference uint64) (Order, bool) {
+	o, ok := b.orders[reference]
+	if !ok {
+		return Order{}, false
+	}
+
+	return o.copy(), true
+}
+
+// Len returns the number of resting orders across all stocks
+func (b *OrderBook) Len() int {
+	return len(b.orders)
+}
+
+// Orders returns the resting orders for one side of a stock in price-time priority, i.e. best price first
+// and oldest first within a price. The book must not be modified during iteration
+func (b *OrderBook) Orders(stockLocate uint16, side itch.OrderIndicator) iter.Seq[Order] {
+	return func(yield func(Order) bool) {
+		l := b.ladder(stockLocate, side)
+		if l == nil {
+			return
+		}
+
+		for i := len(l.levels) - 1; i >= 0; i-- {
+			for o := l.levels[i].head; o != nil; o = o.next {
+				if !yield(o.copy()) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *OrderBook) add(locate uint16, reference uint64, side itch.OrderIndicator, price udecimal.Decimal, shares uint32, timestamp time.Duration, tracking uint16, attribution string) error {
+	if _, ok := b.orders[reference]; ok {
+		return fmt.Errorf("%w %d", ErrDuplicateOrder, reference)
+	}
+
+	s, ok := b.stocks[locate]
+	if !ok {
+		s = &stockBook{
+			bids: ladder{side: itch.ORDER_INDICATOR_BUY, byTicks: make(map[int64]*level)},
+			asks: ladder{side: itch.ORDER_INDICATOR_SELL, byTicks: make(map[int64]*level)},
+		}
+		b.stocks[locate] = s
+	}
+
+	var l *ladder
+	switch side {
+	case itch.ORDER_INDICATOR_BUY:
+		l = &s.bids
+	case itch.ORDER_INDICATOR_SELL:
+		l = &s.asks
+	default:
+		return fmt.Errorf("invalid order indicator=%v for order %d", side, reference)
+	}
+
+	o := &Order{
+		Price:       price,
+		Attribution: attribution,
+		Timestamp:   timestamp,
+		Reference:   reference,
+		Shares:      shares,
+		StockLocate: locate,
+		Side:        side,
+	}
+
+	l.insert(o)
+	b.orders[reference] = o
+
+	b.notify(o.StockLocate, o.Side, o.level, timestamp, tracking)
+
+	return nil
+}
+
+// reduce removes shares from an order following an execution or partial cancel. The order is removed
+// from the book once it has no shares remaining
+func (b *OrderBook) reduce(reference uint64, shares uint32, timestamp time.Duration, tracking uint16) error {
+	o, ok := b.orders[reference]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownOrder, reference)
+	}
+
+	if shares >= o.Shares {
+		b.remove(o, timestamp, tracking)
+		return nil
+	}
+
+	o.Shares -= shares
+	o.level.shares -= uint64(shares)
+
+	b.notify(o.StockLocate, o.Side, o.level, timestamp, tracking)
+
+	return nil
+}
+
+func (b *OrderBook) delete(reference uint64, timestamp time.Duration, tracking uint16) error {
+	o, ok := b.orders[reference]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownOrder, reference)
+	}
+
+	b.remove(o, timestamp, tracking)
+
+	return nil
+}
+
+// replace cancels the original order and adds a new order with the same side and stock. The new order
+// loses its time priority
+func (b *OrderBook) replace(original, reference uint64, price udecimal.Decimal, shares uint32, timestamp time.Duration, tracking uint16) error {
+	o, ok := b.orders[original]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownOrder, original)
+	}
+	if _, ok := b.orders[reference]; ok {
+		return fmt.Errorf("%w %d", ErrDuplicateOrder, reference)
+	}
+
+	b.remove(o, timestamp, tracking)
+
+	return b.add(o.StockLocate, reference, o.Side, price, shares, timestamp, tracking, o.Attribution)
+}
+
+func (b *OrderBook) remove(o *Order, timestamp time.Duration, tracking uint16) {
+	delete(b.orders, o.Reference)
+
+	lvl := o.level
+
+	s := b.stocks[o.StockLocate]
+	if o.Side == itch.ORDER_INDICATOR_BUY {
+		s.bids.unlink(o)
+	} else {
+		s.asks.unlink(o)
+	}
+
+	b.notify(o.StockLocate, o.Side, lvl, timestamp, tracking)
+}
+
+func (b *OrderBook) ladder(locate uint16, side itch.OrderIndicator) *ladder {
+	s, ok := b.stocks[locate]
+	if !ok {
+		return nil
+	}
+
+	switch side {
+	case itch.ORDER_INDICATOR_BUY:
+		return &s.bids
+	case itch.ORDER_INDICATOR_SELL:
+		return &s.asks
+	}
+
+	return nil
+}
+
+// compare orders prices from worst to best for the side of the ladder
+func (l *ladder) compare(a, b int64) int {
+	if l.side == itch.ORDER_INDICATOR_BUY {
+		return cmp.Compare(a, b)
+	}
+
+	return cmp.Compare(b, a)
+}
+
+func (l *ladder) insert(o *Order) {
+	ticks := priceTicks(o.Price)
+
+	lvl, ok := l.byTicks[ticks]
+	if !ok {
+		lvl = &level{price: o.Price, ticks: ticks}
+		l.byTicks[ticks] = lvl
+
+		i, _ := slices.BinarySearchFunc(l.levels, ticks, func(e *level, t int64) int {
+			return l.compare(e.ticks, t)
+		})
+		l.levels = slices.Insert(l.levels, i, lvl)
+	}
+
+	o.level = lvl
+	o.prev = lvl.tail
+	if lvl.tail != nil {
+		lvl.tail.next = o
+	} else {
+		lvl.head = o
+	}
+	lvl.tail = o
+
+	lvl.shares += uint64(o.Shares)
+	lvl.count++
+}
+
+func (l *ladder) unlink(o *Order) {
+	lvl := o.level
+
+	if o.prev != nil {
+		o.prev.next = o.next
+	} else {
+		lvl.head = o.next
+	}
+	if o.next != nil {
+		o.next.prev = o.prev
+	} else {
+		lvl.tail = o.prev
+	}
+
+	lvl.shares -= uint64(o.Shares)
+	lvl.count--
+
+	o.level, o.prev, o.next = nil, nil, nil
+
+	if lvl.count > 0 {
+		return
+	}
+
+	delete(l.byTicks, lvl.ticks)
+
+	i, found := slices.BinarySearchFunc(l.levels, lvl.ticks, func(e *level, t int64) int {
+		return l.compare(e.ticks, t)
+	})
+	if found {
+		l.levels = slices.Delete(l.levels, i, i+1)
+	}
+}
+
+func (o *Order) copy() Order {
+	return Order{
+		Price:       o.Price,
+		Attribution: o.Attribution,
+		Timestamp:   o.Timestamp,
+		Reference:   o.Reference,
+		Shares:      o.Shares,
+		StockLocate: o.StockLocate,
+		Side:        o.Side,
+	}
+}
+
+// priceTicks converts a price into an integer number of ten-thousandths, the precision of Price (4) fields
+func priceTicks(price udecimal.Decimal) int64 {
+	ticks, _ := price.Mul64(10000).Trunc(0).Int64()
+	return ticks
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+func addOrder(reference uint64, side itch.OrderIndicator, price string, shares uint32) itch.OrderAdd {
+	return itch.OrderAdd{
+		StockLocate:    1,
+		Timestamp:      time.Duration(reference) * time.Second,
+		Reference:      reference,
+		OrderIndicator: side,
+		Shares:         shares,
+		Stock:          "AAPL",
+		Price:          udecimal.MustParse(price),
+	}
+}
+
+func references(b *OrderBook, side itch.OrderIndicator) []uint64 {
+	refs := []uint64{}
+	for o := range b.Orders(1, side) {
+		refs = append(refs, o.Reference)
+	}
+	return refs
+}
+
+func TestOrderBook_PriceTimePriority(t *testing.T) {
+	b := New()
+
+	messages := []itch.ItchMessage{
+		addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		addOrder(2, itch.ORDER_INDICATOR_BUY, "10.01", 100),
+		addOrder(3, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		addOrder(4, itch.ORDER_INDICATOR_SELL, "10.03", 100),
+		addOrder(5, itch.ORDER_INDICATOR_SELL, "10.02", 100),
+		addOrder(6, itch.ORDER_INDICATOR_SELL, "10.03", 100),
+	}
+	for _, m := range messages {
+		if err := b.Apply(m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	if got, want := references(b, itch.ORDER_INDICATOR_BUY), []uint64{2, 1, 3}; !slices.Equal(got, want) {
+		t.Errorf("bids = %v, want %v", got, want)
+	}
+	if got, want := references(b, itch.ORDER_INDICATOR_SELL), []uint64{5, 4, 6}; !slices.Equal(got, want) {
+		t.Errorf("asks = %v, want %v", got, want)
+	}
+	if b.Len() != 6 {
+		t.Errorf("Len() = %d, want 6", b.Len())
+	}
+}
+
+func TestOrderBook_ExecuteCancelDelete(t *testing.T) {
+	b := New()
+
+	messages := []itch.ItchMessage{
+		addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 300),
+		addOrder(2, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		itch.OrderExecuted{StockLocate: 1, Reference: 1, Shares: 100, MatchNumber: 1},
+		itch.OrderExecutedPrice{StockLocate: 1, Reference: 1, Shares: 50, MatchNumber: 2, Printable: true, ExecutionPrice: udecimal.MustParse("10.00")},
+		itch.OrderCancel{StockLocate: 1, Reference: 1, Shares: 50},
+		itch.OrderDelete{StockLocate: 1, Reference: 2},
+	}
+	for _, m := range messages {
+		if err := b.Apply(m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	o, ok := b.Order(1)
+	if !ok || o.Shares != 100 {
+		t.Fatalf("Order(1) = %v, %v, want 100 shares", o, ok)
+	}
+	if _, ok := b.Order(2); ok {
+		t.Errorf("Order(2) still on book after delete")
+	}
+
+	if err := b.Apply(itch.OrderExecuted{StockLocate: 1, Reference: 1, Shares: 100, MatchNumber: 3}); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("Len() = %d after full execution, want 0", b.Len())
+	}
+	if got := references(b, itch.ORDER_INDICATOR_BUY); len(got) != 0 {
+		t.Errorf("bids = %v, want none", got)
+	}
+}
+
+func TestOrderBook_Replace(t *testing.T) {
+	b := New()
+
+	messages := []itch.ItchMessage{
+		itch.OrderAddAttributed{
+			StockLocate:    1,
+			Reference:      1,
+			OrderIndicator: itch.ORDER_INDICATOR_SELL,
+			Shares:         100,
+			Stock:          "AAPL",
+			Price:          udecimal.MustParse("10.00"),
+			Attribution:    "NITE",
+		},
+		addOrder(2, itch.ORDER_INDICATOR_SELL, "10.00", 100),
+		itch.OrderReplace{
+			StockLocate:       1,
+			Timestamp:         time.Hour,
+			OriginalReference: 1,
+			NewReference:      3,
+			Shares:            200,
+			Price:             udecimal.MustParse("10.00"),
+		},
+	}
+	for _, m := range messages {
+		if err := b.Apply(m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	if _, ok := b.Order(1); ok {
+		t.Errorf("original order still on book after replace")
+	}
+
+	want := Order{
+		Price:       udecimal.MustParse("10.00"),
+		Attribution: "NITE",
+		Timestamp:   time.Hour,
+		Reference:   3,
+		Shares:      200,
+		StockLocate: 1,
+		Side:        itch.ORDER_INDICATOR_SELL,
+	}
+	got, _ := b.Order(3)
+	if !cmp.Equal(got, want, cmpopts.IgnoreUnexported(Order{})) {
+		t.Errorf("%v", cmp.Diff(want, got, cmpopts.IgnoreUnexported(Order{})))
+	}
+
+	// The replaced order loses time priority
+	if got, want := references(b, itch.ORDER_INDICATOR_SELL), []uint64{2, 3}; !slices.Equal(got, want) {
+		t.Errorf("asks = %v, want %v", got, want)
+	}
+}
+
+func TestOrderBook_Errors(t *testing.T) {
+	b := New()
+
+	if err := b.Apply(addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100)); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if err := b.Apply(addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100)); !errors.Is(err, ErrDuplicateOrder) {
+		t.Errorf("Apply() duplicate add error = %v, want %v", err, ErrDuplicateOrder)
+	}
+	if err := b.Apply(itch.OrderDelete{Reference: 2}); !errors.Is(err, ErrUnknownOrder) {
+		t.Errorf("Apply() unknown delete error = %v, want %v", err, ErrUnknownOrder)
+	}
+	if err := b.Apply(itch.OrderReplace{OriginalReference: 2, NewReference: 3}); !errors.Is(err, ErrUnknownOrder) {
+		t.Errorf("Apply() unknown replace error = %v, want %v", err, ErrUnknownOrder)
+	}
+
+	// Handler methods ignore unknown orders
+	b.OnOrderExecuted(itch.OrderExecuted{Reference: 2, Shares: 100})
+	if b.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", b.Len())
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+import (
+	"time"
+
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+// PriceLevel is the aggregated (L2) view of all orders resting at one price
+type PriceLevel struct {
+	Price  udecimal.Decimal // Price (4)
+	Shares uint64
+	Orders int
+}
+
+// Snapshot holds the top price levels of a stock's book, best price first
+type Snapshot struct {
+	Bids        []PriceLevel
+	Asks        []PriceLevel
+	StockLocate uint16
+}
+
+// LevelUpdate describes a price level after an ITCH message changed it. A level that has been
+// removed from the book is reported with zero Shares and Orders
+type LevelUpdate struct {
+	Price          udecimal.Decimal // Price (4)
+	Timestamp      time.Duration
+	Shares         uint64
+	Orders         int
+	StockLocate    uint16
+	TrackingNumber uint16
+	Side           itch.OrderIndicator
+}
+
+// Depth returns up to n price levels on each side of a stock's book. If n is zero or negative
+// every level is returned
+func (b *OrderBook) Depth(stockLocate uint16, n int) Snapshot {
+	snapshot := Snapshot{StockLocate: stockLocate}
+
+	s, ok := b.stocks[stockLocate]
+	if !ok {
+		return snapshot
+	}
+
+	snapshot.Bids = s.bids.depth(n)
+	snapshot.Asks = s.asks.depth(n)
+
+	return snapshot
+}
+
+func (l *ladder) depth(n int) []PriceLevel {
+	if n <= 0 || n > len(l.levels) {
+		n = len(l.levels)
+	}
+
+	levels := make([]PriceLevel, 0, n)
+	for i := len(l.levels) - 1; i >= len(l.levels)-n; i-- {
+		levels = append(levels, l.levels[i].priceLevel())
+	}
+
+	return levels
+}
+
+func (l *level) priceLevel() PriceLevel {
+	return PriceLevel{
+		Price:  l.price,
+		Shares: l.shares,
+		Orders: l.count,
+	}
+}
+
+func (b *OrderBook) notify(locate uint16, side itch.OrderIndicator, lvl *level, timestamp time.Duration, tracking uint16) {
+	if b.levelCallback == nil {
+		return
+	}
+
+	b.levelCallback(LevelUpdate{
+		Price:          lvl.price,
+		Timestamp:      timestamp,
+		Shares:         lvl.shares,
+		Orders:         lvl.count,
+		StockLocate:    locate,
+		TrackingNumber: tracking,
+		Side:           side,
+	})
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+func TestOrderBook_Depth(t *testing.T) {
+	b := New()
+
+	messages := []itch.ItchMessage{
+		addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		addOrder(2, itch.ORDER_INDICATOR_BUY, "10.01", 200),
+		addOrder(3, itch.ORDER_INDICATOR_BUY, "10.00", 300),
+		addOrder(4, itch.ORDER_INDICATOR_BUY, "9.99", 400),
+		addOrder(5, itch.ORDER_INDICATOR_SELL, "10.03", 100),
+		addOrder(6, itch.ORDER_INDICATOR_SELL, "10.02", 100),
+	}
+	for _, m := range messages {
+		if err := b.Apply(m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want Snapshot
+	}{
+		{
+			name: "top 2",
+			n:    2,
+			want: Snapshot{
+				StockLocate: 1,
+				Bids: []PriceLevel{
+					{Price: udecimal.MustParse("10.01"), Shares: 200, Orders: 1},
+					{Price: udecimal.MustParse("10.00"), Shares: 400, Orders: 2},
+				},
+				Asks: []PriceLevel{
+					{Price: udecimal.MustParse("10.02"), Shares: 100, Orders: 1},
+					{Price: udecimal.MustParse("10.03"), Shares: 100, Orders: 1},
+				},
+			},
+		},
+		{
+			name: "all levels",
+			n:    0,
+			want: Snapshot{
+				StockLocate: 1,
+				Bids: []PriceLevel{
+					{Price: udecimal.MustParse("10.01"), Shares: 200, Orders: 1},
+					{Price: udecimal.MustParse("10.00"), Shares: 400, Orders: 2},
+					{Price: udecimal.MustParse("9.99"), Shares: 400, Orders: 1},
+				},
+				Asks: []PriceLevel{
+					{Price: udecimal.MustParse("10.02"), Shares: 100, Orders: 1},
+					{Price: udecimal.MustParse("10.03"), Shares: 100, Orders: 1},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := b.Depth(1, tt.n)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("%v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+
+	if got := b.Depth(2, 5); len(got.Bids) != 0 || len(got.Asks) != 0 {
+		t.Errorf("Depth() for unknown stock = %v, want empty", got)
+	}
+}
+
+func TestOrderBook_LevelUpdates(t *testing.T) {
+	updates := []LevelUpdate{}
+	b := New(WithLevelCallback(func(u LevelUpdate) {
+		updates = append(updates, u)
+	}))
+
+	messages := []itch.ItchMessage{
+		addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		addOrder(2, itch.ORDER_INDICATOR_BUY, "10.00", 200),
+		itch.OrderExecuted{StockLocate: 1, Timestamp: time.Hour, TrackingNumber: 7, Reference: 1, Shares: 50},
+		itch.OrderReplace{StockLocate: 1, Timestamp: 2 * time.Hour, OriginalReference: 2, NewReference: 3, Shares: 200, Price: udecimal.MustParse("10.01")},
+		itch.OrderDelete{StockLocate: 1, Timestamp: 3 * time.Hour, Reference: 1},
+	}
+	for _, m := range messages {
+		if err := b.Apply(m); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	want := []LevelUpdate{
+		{Price: udecimal.MustParse("10.00"), Timestamp: time.Second, Shares: 100, Orders: 1, StockLocate: 1, Side: itch.ORDER_INDICATOR_BUY},
+		{Price: udecimal.MustParse("10.00"), Timestamp: 2 * time.Second, Shares: 300, Orders: 2, StockLocate: 1, Side: itch.ORDER_INDICATOR_BUY},
+		{Price: udecimal.MustParse("10.00"), Timestamp: time.Hour, Shares: 250, Orders: 2, StockLocate: 1, TrackingNumber: 7, Side: itch.ORDER_INDICATOR_BUY},
+		{Price: udecimal.MustParse("10.00"), Timestamp: 2 * time.Hour, Shares: 50, Orders: 1, StockLocate: 1, Side: itch.ORDER_INDICATOR_BUY},
+		{Price: udecimal.MustParse("10.01"), Timestamp: 2 * time.Hour, Shares: 200, Orders: 1, StockLocate: 1, Side: itch.ORDER_INDICATOR_BUY},
+		{Price: udecimal.MustParse("10.00"), Timestamp: 3 * time.Hour, Shares: 0, Orders: 0, StockLocate: 1, Side: itch.ORDER_INDICATOR_BUY},
+	}
+
+	if !cmp.Equal(updates, want) {
+		t.Errorf("%v", cmp.Diff(want, updates))
+	}
+}
@@ -0,0 +1,14 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+type Option func(b *OrderBook)
+
+// WithLevelCallback sets the callback function called every time an ITCH message changes a price level
+func WithLevelCallback(callback func(LevelUpdate)) Option {
+	return func(b *OrderBook) {
+		b.levelCallback = callback
+	}
+}
@@ -0,0 +1,319 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+var ErrUnknownTrade = errors.New("unknown trade match number")
+
+// Trade is a single print on the time and sales tape
+type Trade struct {
+	Stock       string
+	Price       udecimal.Decimal // Price (4)
+	Timestamp   time.Duration
+	MatchNumber uint64
+	Shares      uint64
+	StockLocate uint16
+	// Side is the side of the resting order. It is zero for cross trades
+	Side itch.OrderIndicator
+	// CrossType is the type of cross for trades printed by a TradeCross message. It is zero for all other trades
+	CrossType itch.CrossType
+}
+
+// TapeUpdate is passed to the TradeTape callback when a trade is printed, or when a previously printed
+// trade is broken and removed from the tape
+type TapeUpdate struct {
+	Trade  Trade
+	Broken bool
+}
+
+// TradeTape builds the time and sales tape from executions, non-cross and cross trade messages.
+// It keeps order level state to resolve the price and side of OrderExecuted messages, which only
+// refer to the resting order. Non-printable executions are not added to the tape and a TradeBroken
+// message removes the trade with the same match number.
+//
+// The tape keeps every trade of the day unless WithRetention limits it to the most recent trades.
+//
+// The Handler methods ignore executions of unknown orders and breaks of unknown trades, which is normal
+// when joining a feed part way through the day. Use Apply to observe these errors instead.
+//
+// TradeTape implements itch.Handler so it can be passed straight to itch.DecodeTo.
+type TradeTape struct {
+	itch.NopHandler
+
+	book    *OrderBook
+	symbols map[uint16]string
+
+	// trades holds every retained print in order, including broken trades which are marked rather than
+	// removed. matches maps a match number to its position counted from the first print of the day, so
+	// positions stay valid when old trades are dropped from the front
+	trades    []tapeEntry
+	matches   map[uint64]uint64
+	first     uint64
+	live      int
+	retention int
+
+	callback func(TapeUpdate)
+}
+
+type tapeEntry struct {
+	trade  Trade
+	broken bool
+}
+
+// TapeOption configures a TradeTape
+type TapeOption func(t *TradeTape)
+
+// WithRetention keeps only the most recent n prints on the tape, including any of them that were broken.
+// Older trades are dropped and can no longer be looked up or broken. n <= 0 keeps every trade
+func WithRetention(n int) TapeOption {
+	return func(t *TradeTape) {
+		t.retention = n
+	}
+}
+
+// NewTradeTape creates an empty TradeTape that calls callback every time a trade is printed or broken.
+// callback may be nil
+func NewTradeTape(callback func(TapeUpdate), opts ...TapeOption) *TradeTape {
+	t := &TradeTape{
+		book:     New(),
+		symbols:  make(map[uint16]string),
+		matches:  make(map[uint64]uint64),
+		callback: callback,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Apply updates the tape with a single ITCH message. Message types that do not affect the tape or the
+// orders it tracks are ignored
+func (t *TradeTape) Apply(msg itch.ItchMessage) error {
+	switch m := msg.(type) {
+	case itch.StockDirectory:
+		t.learn(m.StockLocate, m.Stock)
+	case itch.OrderAdd:
+		t.learn(m.StockLocate, m.Stock)
+		return t.book.Apply(m)
+	case itch.OrderAddAttributed:
+		t.learn(m.StockLocate, m.Stock)
+		return t.book.Apply(m)
+	case itch.OrderExecuted:
+		o, ok := t.book.Order(m.Reference)
+		if !ok {
+			return fmt.Errorf("%w %d", ErrUnknownOrder, m.Reference)
+		}
+		if err := t.book.Apply(m); err != nil {
+			return err
+		}
+
+		t.print(Trade{
+			Stock:       t.symbols[m.StockLocate],
+			Price:       o.Price,
+			Timestamp:   m.Timestamp,
+			MatchNumber: m.MatchNumber,
+			Shares:      uint64(m.Shares),
+			StockLocate: m.StockLocate,
+			Side:        o.Side,
+		})
+	case itch.OrderExecutedPrice:
+		o, ok := t.book.Order(m.Reference)
+		if !ok {
+			return fmt.Errorf("%w %d", ErrUnknownOrder, m.Reference)
+		}
+		if err := t.book.Apply(m); err != nil {
+			return err
+		}
+
+		if !m.Printable {
+			return nil
+		}
+
+		t.print(Trade{
+			Stock:       t.symbols[m.StockLocate],
+			Price:       m.ExecutionPrice,
+			Timestamp:   m.Timestamp,
+			MatchNumber: m.MatchNumber,
+			Shares:      uint64(m.Shares),
+			StockLocate: m.StockLocate,
+			Side:        o.Side,
+		})
+	case itch.OrderCancel, itch.OrderDelete, itch.OrderReplace:
+		return t.book.Apply(msg)
+	case itch.TradeNonCross:
+		t.print(Trade{
+			Stock:       m.Stock,
+			Price:       m.Price,
+			Timestamp:   m.Timestamp,
+			MatchNumber: m.MatchNumber,
+			Shares:      uint64(m.Shares),
+			StockLocate: m.StockLocate,
+			Side:        m.OrderIndicator,
+		})
+	case itch.TradeCross:
+		// A cross with no shares means the cross did not execute
+		if m.Shares == 0 {
+			return nil
+		}
+
+		t.print(Trade{
+			Stock:       m.Stock,
+			Price:       m.CrossPrice,
+			Timestamp:   m.Timestamp,
+			MatchNumber: m.MatchNumber,
+			Shares:      m.Shares,
+			StockLocate: m.StockLocate,
+			CrossType:   m.CrossType,
+		})
+	case itch.TradeBroken:
+		return t.breakTrade(m.MatchNumber)
+	}
+
+	return nil
+}
+
+func (t *TradeTape) OnStockDirectory(m itch.StockDirectory) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnOrderAdd(m itch.OrderAdd) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnOrderAddAttributed(m itch.OrderAddAttributed) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnOrderExecuted(m itch.OrderExecuted) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnOrderExecutedPrice(m itch.OrderExecutedPrice) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnOrderCancel(m itch.OrderCancel) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnOrderDelete(m itch.OrderDelete) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnOrderReplace(m itch.OrderReplace) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnTradeNonCross(m itch.TradeNonCross) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnTradeCross(m itch.TradeCross) {
+	_ = t.Apply(m)
+}
+
+func (t *TradeTape) OnTradeBroken(m itch.TradeBroken) {
+	_ = t.Apply(m)
+}
+
+// Trade returns the trade with the given match number. Broken trades and trades dropped by WithRetention
+// are not returned
+func (t *TradeTape) Trade(matchNumber uint64) (Trade, bool) {
+	i, ok := t.matches[matchNumber]
+	if !ok {
+		return Trade{}, false
+	}
+
+	return t.trades[i-t.first].trade, true
+}
+
+// Len returns the number of trades on the tape, not counting broken trades
+func (t *TradeTape) Len() int {
+	return t.live
+}
+
+// Trades returns the trades on the tape in the order they were printed, skipping broken trades. The tape
+// must not be modified during iteration
+func (t *TradeTape) Trades() iter.Seq[Trade] {
+	return func(yield func(Trade) bool) {
+		for _, e := range t.trades {
+			if e.broken {
+				continue
+			}
+			if !yield(e.trade) {
+				return
+			}
+		}
+	}
+}
+
+// learn records the symbol of a stock locate so executions, which do not carry the symbol, can be printed with it
+func (t *TradeTape) learn(locate uint16, stock string) {
+	if stock != "" {
+		t.symbols[locate] = stock
+	}
+}
+
+func (t *TradeTape) print(trade Trade) {
+	t.matches[trade.MatchNumber] = t.first + uint64(len(t.trades))
+	t.trades = append(t.trades, tapeEntry{trade: trade})
+	t.live++
+
+	if t.retention > 0 && len(t.trades) > t.retention {
+		t.drop()
+	}
+
+	if t.callback != nil {
+		t.callback(TapeUpdate{Trade: trade})
+	}
+}
+
+// drop removes the oldest print from the tape. The slice is advanced rather than shifted, and append moves
+// the retained trades to a new array once the spare capacity is used up
+func (t *TradeTape) drop() {
+	oldest := t.trades[0]
+	if !oldest.broken {
+		// A later print may have reused the match number
+		if t.matches[oldest.trade.MatchNumber] == t.first {
+			delete(t.matches, oldest.trade.MatchNumber)
+		}
+		t.live--
+	}
+
+	t.trades[0] = tapeEntry{}
+	t.trades = t.trades[1:]
+	t.first++
+}
+
+// breakTrade marks a trade as broken. It stays in place so the positions of later trades do not change
+func (t *TradeTape) breakTrade(matchNumber uint64) error {
+	i, ok := t.matches[matchNumber]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownTrade, matchNumber)
+	}
+
+	e := &t.trades[i-t.first]
+	e.broken = true
+
+	delete(t.matches, matchNumber)
+	t.live--
+
+	if t.callback != nil {
+		t.callback(TapeUpdate{Trade: e.trade, Broken: true})
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package book
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+func TestTradeTape(t *testing.T) {
+	updates := []TapeUpdate{}
+	tape := NewTradeTape(func(update TapeUpdate) {
+		updates = append(updates, update)
+	})
+
+	messages := []itch.ItchMessage{
+		addOrder(1, itch.ORDER_INDICATOR_BUY, "10.00", 100),
+		addOrder(2, itch.ORDER_INDICATOR_SELL, "10.05", 100),
+		// Resolves the price and side from the resting order
+		itch.OrderExecuted{StockLocate: 1, Timestamp: time.Hour, Reference: 1, Shares: 40, MatchNumber: 10},
+		// Non-printable executions update the book but are not on the tape
+		itch.OrderExecutedPrice{StockLocate: 1, Timestamp: 2 * time.Hour, Reference: 2, Shares: 20, MatchNumber: 11, ExecutionPrice: udecimal.MustParse("10.04")},
+		itch.OrderExecutedPrice{StockLocate: 1, Timestamp: 3 * time.Hour, Reference: 2, Shares: 30, MatchNumber: 12, ExecutionPrice: udecimal.MustParse("10.03"), Printable: true},
+		itch.TradeNonCross{StockLocate: 1, Timestamp: 4 * time.Hour, Stock: "AAPL", OrderIndicator: itch.ORDER_INDICATOR_BUY, Shares: 500, Price: udecimal.MustParse("10.01"), MatchNumber: 13},
+		// A cross with no shares is not printed
+		itch.TradeCross{StockLocate: 1, Timestamp: 5 * time.Hour, Stock: "AAPL", CrossPrice: udecimal.MustParse("10.02"), MatchNumber: 14, CrossType: itch.CROSS_TYPE_NASDAQ_CLOSE},
+		itch.TradeCross{StockLocate: 1, Timestamp: 6 * time.Hour, Stock: "AAPL", Shares: 1000, CrossPrice: udecimal.MustParse("10.02"), MatchNumber: 15, CrossType: itch.CROSS_TYPE_NASDAQ_CLOSE},
+		itch.TradeBroken{StockLocate: 1, Timestamp: 7 * time.Hour, MatchNumber: 12},
+	}
+	for _, m := range messages {
+		if err := tape.Apply(m); err != nil {
+			t.Fatalf("Apply(%T) error = %v", m, err)
+		}
+	}
+
+	executed := Trade{Stock: "AAPL", Price: udecimal.MustParse("10.00"), Timestamp: time.Hour, MatchNumber: 10, Shares: 40, StockLocate: 1, Side: itch.ORDER_INDICATOR_BUY}
+	executedPrice := Trade{Stock: "AAPL", Price: udecimal.MustParse("10.03"), Timestamp: 3 * time.Hour, MatchNumber: 12, Shares: 30, StockLocate: 1, Side: itch.ORDER_INDICATOR_SELL}
+	nonCross := Trade{Stock: "AAPL", Price: udecimal.MustParse("10.01"), Timestamp: 4 * time.Hour, MatchNumber: 13, Shares: 500, StockLocate: 1, Side: itch.ORDER_INDICATOR_BUY}
+	cross := Trade{Stock: "AAPL", Price: udecimal.MustParse("10.02"), Timestamp: 6 * time.Hour, MatchNumber: 15, Shares: 1000, StockLocate: 1, CrossType: itch.CROSS_TYPE_NASDAQ_CLOSE}
+
+	wantUpdates := []TapeUpdate{
+		{Trade: executed},
+		{Trade: executedPrice},
+		{Trade: nonCross},
+		{Trade: cross},
+		{Trade: executedPrice, Broken: true},
+	}
+	if !cmp.Equal(updates, wantUpdates) {
+		t.Errorf("updates: %v", cmp.Diff(wantUpdates, updates))
+	}
+
+	wantTrades := []Trade{executed, nonCross, cross}
+	if got := slices.Collect(tape.Trades()); !cmp.Equal(got, wantTrades) {
+		t.Errorf("Trades(): %v", cmp.Diff(wantTrades, got))
+	}
+
+	if tape.Len() != len(wantTrades) {
+		t.Errorf("Len() = %d, want %d", tape.Len(), len(wantTrades))
+	}
+
+	if _, ok := tape.Trade(12); ok {
+		t.Errorf("Trade(12) found a broken trade")
+	}
+	if got, ok := tape.Trade(15); !ok || !cmp.Equal(got, cross) {
+		t.Errorf("Trade(15) = %v, %v, want %v", got, ok, cross)
+	}
+
+	// The book still tracks the executed shares
+	if o, ok := tape.book.Order(2); !ok || o.Shares != 50 {
+		t.Errorf("Order(2) = %v, %v, want 50 shares", o, ok)
+	}
+}
+
+func TestTradeTape_Retention(t *testing.T) {
+	tape := NewTradeTape(nil, WithRetention(3))
+
+	trade := func(match uint64) itch.TradeNonCross {
+		return itch.TradeNonCross{StockLocate: 1, Timestamp: time.Duration(match) * time.Hour, Stock: "AAPL", OrderIndicator: itch.ORDER_INDICATOR_BUY, Shares: 100, Price: udecimal.MustParse("10.00"), MatchNumber: match}
+	}
+
+	messages := []itch.ItchMessage{
+		trade(1),
+		trade(2),
+		trade(3),
+		itch.TradeBroken{StockLocate: 1, MatchNumber: 2},
+		// Drops trade 1, the broken trade 2 is still retained
+		trade(4),
+		// Drops the broken trade 2
+		trade(5),
+	}
+	for _, m := range messages {
+		if err := tape.Apply(m); err != nil {
+			t.Fatalf("Apply(%T) error = %v", m, err)
+		}
+	}
+
+	got := []uint64{}
+	for trade := range tape.Trades() {
+		got = append(got, trade.MatchNumber)
+	}
+	if want := []uint64{3, 4, 5}; !cmp.Equal(got, want) || tape.Len() != len(want) {
+		t.Errorf("Trades() = %v, Len() = %d, want %v", got, tape.Len(), want)
+	}
+
+	for _, match := range []uint64{1, 2} {
+		if _, ok := tape.Trade(match); ok {
+			t.Errorf("Trade(%d) found a trade that should not be retained", match)
+		}
+	}
+	if got, ok := tape.Trade(4); !ok || got.Timestamp != 4*time.Hour {
+		t.Errorf("Trade(4) = %v, %v", got, ok)
+	}
+
+	// Trades that were dropped can no longer be broken
+	if err := tape.Apply(itch.TradeBroken{MatchNumber: 1}); !errors.Is(err, ErrUnknownTrade) {
+		t.Errorf("Apply(TradeBroken) error = %v, want %v", err, ErrUnknownTrade)
+	}
+	if err := tape.Apply(itch.TradeBroken{MatchNumber: 4}); err != nil {
+		t.Fatalf("Apply(TradeBroken) error = %v", err)
+	}
+	if _, ok := tape.Trade(5); !ok || tape.Len() != 2 {
+		t.Errorf("Trade(5) not found after breaking trade 4, Len() = %d", tape.Len())
+	}
+}
+
+func TestTradeTape_StockDirectory(t *testing.T) {
+	tape := NewTradeTape(nil)
+
+	messages := []itch.ItchMessage{
+		itch.StockDirectory{StockLocate: 2, Stock: "MSFT"},
+		itch.OrderAdd{StockLocate: 2, Reference: 1, OrderIndicator: itch.ORDER_INDICATOR_SELL, Shares: 100, Price: udecimal.MustParse("300.00")},
+		itch.OrderExecuted{StockLocate: 2, Reference: 1, Shares: 100, MatchNumber: 1},
+	}
+	for _, m := range messages {
+		if err := tape.Apply(m); err != nil {
+			t.Fatalf("Apply(%T) error = %v", m, err)
+		}
+	}
+
+	got, ok := tape.Trade(1)
+	if !ok || got.Stock != "MSFT" {
+		t.Errorf("Trade(1) = %v, %v, want stock MSFT", got, ok)
+	}
+}
+
+func TestTradeTape_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  itch.ItchMessage
+		want error
+	}{
+		{name: "executed unknown order", msg: itch.OrderExecuted{Reference: 1, MatchNumber: 1}, want: ErrUnknownOrder},
+		{name: "executed price unknown order", msg: itch.OrderExecutedPrice{Reference: 1, MatchNumber: 1, Printable: true}, want: ErrUnknownOrder},
+		{name: "broken unknown trade", msg: itch.TradeBroken{MatchNumber: 1}, want: ErrUnknownTrade},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tape := NewTradeTape(nil)
+
+			if err := tape.Apply(tt.msg); !errors.Is(err, tt.want) {
+				t.Errorf("Apply() error = %v, want %v", err, tt.want)
+			}
+			if tape.Len() != 0 {
+				t.Errorf("Len() = %d, want 0", tape.Len())
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+// Command itch-parquet converts an ITCH file to Parquet files, one per message type
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/markwinter/go-finproto/itch/5.0/columnar"
+)
+
+func main() {
+	filePath := flag.String("file", "", "Path to ITCH file")
+	out := flag.String("out", ".", "Directory to write the Parquet files to")
+	prefixed := flag.Bool("prefixed", true, "Whether each ITCH message is prefixed by a two byte length field")
+	types := flag.String("types", "", "Message types to export, such as AFE. Defaults to every type")
+	symbols := flag.String("symbols", "", "Comma separated stock symbols to export. Defaults to every stock")
+	batchSize := flag.Int("batch", columnar.DefaultBatchSize, "Number of rows in each record batch")
+	flag.Parse()
+
+	if *filePath == "" {
+		log.Fatal("-file is required")
+	}
+
+	config := itch.Configuration{
+		MessageTypes:        []byte(*types),
+		ReadBufferSize:      1 << 20,
+		LengthFieldPrefixed: *prefixed,
+	}
+	if *symbols != "" {
+		config.Symbols = strings.Split(*symbols, ",")
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	decoder, err := itch.OpenFile(*filePath, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer decoder.Close()
+
+	e := columnar.NewParquetExporter(*out, columnar.WithBatchSize(*batchSize))
+
+	log.Printf("Exporting %s as parquet to %s", *filePath, *out)
+
+	count := 0
+	for message, err := range decoder.All() {
+		if err != nil {
+			// Messages that fail to parse are skipped, an error that stopped the decoder is reported below
+			if decoder.Err() != nil {
+				break
+			}
+			log.Print(err)
+			continue
+		}
+
+		if err := e.Write(message); err != nil {
+			if closeErr := e.Close(); closeErr != nil {
+				log.Print(closeErr)
+			}
+			log.Fatal(err)
+		}
+		count++
+	}
+
+	if err := decoder.Err(); err != nil {
+		if closeErr := e.Close(); closeErr != nil {
+			log.Print(closeErr)
+		}
+		log.Fatal(err)
+	}
+
+	if err := e.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Exported %d messages", count)
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+// Package columnar converts ITCH messages into Apache Arrow record batches, with a separate schema for each
+// message type, and writes them to Parquet files.
+//
+// Each message field becomes a column named after its JSON field name, as described by itch.MessageFields.
+// Timestamps are time64 nanoseconds since midnight, prices are int64 scaled by 10^4 (10^8 for MWCB levels)
+// with the scale stored in the field metadata, and symbols, other strings and enum codes are dictionary
+// encoded strings.
+package columnar
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+const DefaultBatchSize = 1 << 16
+
+var dictionaryType = &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String}
+
+// Exporter collects messages into an Arrow record batch for each message type and passes each batch on once
+// it is full. It is not safe for concurrent use.
+type Exporter struct {
+	mem       memory.Allocator
+	batchSize int
+
+	tables map[uint8]*table
+	handle func(name string, record arrow.Record) error
+	close  func() error
+}
+
+// table builds the record batches of a single message type
+type table struct {
+	name    string
+	builder *array.RecordBuilder
+	columns []column
+	rows    int
+}
+
+// column appends one struct field of a message to its Arrow builder
+type column struct {
+	index  int
+	append func(b array.Builder, v reflect.Value) error
+}
+
+type Option func(e *Exporter)
+
+// WithBatchSize sets the number of rows in each record batch. The default is 65536
+func WithBatchSize(size int) Option {
+	return func(e *Exporter) {
+		e.batchSize = size
+	}
+}
+
+// WithAllocator sets the memory allocator used to build record batches
+func WithAllocator(mem memory.Allocator) Option {
+	return func(e *Exporter) {
+		e.mem = mem
+	}
+}
+
+// NewExporter creates an Exporter that calls handle with each record batch and the name of its message's Go
+// type, such as OrderAdd. The record is released after handle returns, so handle must call Retain to keep it
+func NewExporter(handle func(name string, record arrow.Record) error, opts ...Option) *Exporter {
+	e := &Exporter{
+		mem:       memory.DefaultAllocator,
+		batchSize: DefaultBatchSize,
+		tables:    make(map[uint8]*table),
+		handle:    handle,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.batchSize <= 0 {
+		e.batchSize = DefaultBatchSize
+	}
+
+	return e
+}
+
+// Schema returns the Arrow schema used for the type of msg
+func Schema(msg itch.ItchMessage) (*arrow.Schema, error) {
+	msg, _, err := messageValue(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, _, err := newSchema(msg)
+	return schema, err
+}
+
+// Write adds a message to the record batch of its type, passing the batch on if it is full. Pointers to
+// messages are written as their value. If appending a field fails the batch may hold a partial row, so the
+// Exporter should be closed after an error
+func (e *Exporter) Write(msg itch.ItchMessage) error {
+	msg, v, err := messageValue(msg)
+	if err != nil {
+		return err
+	}
+
+	t, ok := e.tables[msg.Type()]
+	if !ok {
+		if t, err = e.newTable(msg); err != nil {
+			return err
+		}
+		e.tables[msg.Type()] = t
+	}
+
+	for i, c := range t.columns {
+		if err := c.append(t.builder.Field(i), v.Field(c.index)); err != nil {
+			return fmt.Errorf("columnar: %s.%s: %w", t.name, t.builder.Schema().Field(i).Name, err)
+		}
+	}
+	t.rows++
+
+	if t.rows >= e.batchSize {
+		return e.emit(t)
+	}
+
+	return nil
+}
+
+// Flush passes on every partly filled record batch
+func (e *Exporter) Flush() error {
+	allErrs := error(nil)
+
+	for _, t := range e.tables {
+		if t.rows > 0 {
+			allErrs = errors.Join(allErrs, e.emit(t))
+		}
+	}
+
+	return allErrs
+}
+
+// Close flushes any partly filled record batches and releases the builders. If the Exporter was created with
+// NewParquetExporter the files are finished and closed
+func (e *Exporter) Close() error {
+	allErrs := e.Flush()
+
+	for _, t := range e.tables {
+		t.builder.Release()
+	}
+	clear(e.tables)
+
+	if e.close != nil {
+		allErrs = errors.Join(allErrs, e.close())
+		e.close = nil
+	}
+
+	return allErrs
+}
+
+func (e *Exporter) emit(t *table) error {
+	record := t.builder.NewRecord()
+	defer record.Release()
+
+	t.rows = 0
+
+	return e.handle(t.name, record)
+}
+
+func (e *Exporter) newTable(msg itch.ItchMessage) (*table, error) {
+	schema, columns, err := newSchema(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &table{
+		name:    reflect.TypeOf(msg).Name(),
+		builder: array.NewRecordBuilder(e.mem, schema),
+		columns: columns,
+	}, nil
+}
+
+// messageValue returns msg, dereferenced if it is a pointer, and its struct value
+func messageValue(msg itch.ItchMessage) (itch.ItchMessage, reflect.Value, error) {
+	v, err := itch.MessageValue(msg)
+	if err != nil {
+		return nil, reflect.Value{}, err
+	}
+
+	return v.Interface().(itch.ItchMessage), v, nil
+}
+
+// newSchema returns the schema for the type of msg, which must be a struct, and how to append each of its fields
+func newSchema(msg itch.ItchMessage) (*arrow.Schema, []column, error) {
+	t := reflect.TypeOf(msg)
+
+	fields := []arrow.Field{}
+	columns := []column{}
+
+	for _, f := range itch.MessageFields(t) {
+		field := arrow.Field{Name: f.Name}
+		c := column{index: f.Index}
+
+		switch f.Kind {
+		case itch.FIELD_KIND_TIMESTAMP:
+			field.Type = arrow.FixedWidthTypes.Time64ns
+			c.append = func(b array.Builder, v reflect.Value) error {
+				b.(*array.Time64Builder).Append(arrow.Time64(v.Int()))
+				return nil
+			}
+		case itch.FIELD_KIND_PRICE:
+			scale := priceScale(msg.Type())
+			multiplier := uint64(1)
+			for range scale {
+				multiplier *= 10
+			}
+
+			field.Type = arrow.PrimitiveTypes.Int64
+			field.Metadata = arrow.NewMetadata([]string{"scale"}, []string{strconv.Itoa(scale)})
+			c.append = func(b array.Builder, v reflect.Value) error {
+				price, _ := v.Interface().(udecimal.Decimal).Mul64(multiplier).Trunc(0).Int64()
+				b.(*array.Int64Builder).Append(price)
+				return nil
+			}
+		case itch.FIELD_KIND_CODE:
+			field.Type = dictionaryType
+			c.append = func(b array.Builder, v reflect.Value) error {
+				return b.(*array.BinaryDictionaryBuilder).AppendString(itch.CodeString(uint8(v.Uint())))
+			}
+		case itch.FIELD_KIND_STRING:
+			field.Type = dictionaryType
+			c.append = func(b array.Builder, v reflect.Value) error {
+				return b.(*array.BinaryDictionaryBuilder).AppendString(v.String())
+			}
+		case itch.FIELD_KIND_BOOL:
+			field.Type = arrow.FixedWidthTypes.Boolean
+			c.append = func(b array.Builder, v reflect.Value) error {
+				b.(*array.BooleanBuilder).Append(v.Bool())
+				return nil
+			}
+		case itch.FIELD_KIND_INTEGER:
+			switch f.Type.Kind() {
+			case reflect.Uint16:
+				field.Type = arrow.PrimitiveTypes.Uint16
+				c.append = func(b array.Builder, v reflect.Value) error {
+					b.(*array.Uint16Builder).Append(uint16(v.Uint()))
+					return nil
+				}
+			case reflect.Uint32:
+				field.Type = arrow.PrimitiveTypes.Uint32
+				c.append = func(b array.Builder, v reflect.Value) error {
+					b.(*array.Uint32Builder).Append(uint32(v.Uint()))
+					return nil
+				}
+			default:
+				field.Type = arrow.PrimitiveTypes.Uint64
+				c.append = func(b array.Builder, v reflect.Value) error {
+					b.(*array.Uint64Builder).Append(v.Uint())
+					return nil
+				}
+			}
+		default:
+			return nil, nil, fmt.Errorf("columnar: unsupported field %s.%s of type %s", t.Name(), f.Name, f.Type)
+		}
+
+		fields = append(fields, field)
+		columns = append(columns, c)
+	}
+
+	metadata := arrow.NewMetadata([]string{"itch.type"}, []string{string(rune(msg.Type()))})
+
+	return arrow.NewSchema(fields, &metadata), columns, nil
+}
+
+// priceScale returns the number of decimal places of the prices in a message type
+func priceScale(messageType uint8) int {
+	if messageType == itch.MESSAGE_MWCB_LEVEL {
+		return 8
+	}
+
+	return 4
+}
@@ -0,0 +1,254 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package columnar
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/google/go-cmp/cmp"
+	itch "github.com/markwinter/go-finproto/itch/5.0"
+	"github.com/quagmt/udecimal"
+)
+
+func orderAdds() []itch.ItchMessage {
+	stocks := []string{"AAPL", "MSFT", "ERIC"}
+
+	messages := []itch.ItchMessage{}
+	for i := range 10 {
+		messages = append(messages, itch.OrderAdd{
+			Stock:          stocks[i%len(stocks)],
+			StockLocate:    uint16(i%len(stocks) + 1),
+			Timestamp:      9*time.Hour + time.Duration(i)*time.Second,
+			Reference:      uint64(i),
+			Shares:         100,
+			Price:          udecimal.MustParse("300.0125"),
+			OrderIndicator: itch.ORDER_INDICATOR_BUY,
+		})
+	}
+
+	return messages
+}
+
+func TestExporter(t *testing.T) {
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	rows := map[string]int64{}
+	batches := 0
+
+	e := NewExporter(func(name string, record arrow.Record) error {
+		rows[name] += record.NumRows()
+		batches++
+
+		if name != "OrderAdd" {
+			return nil
+		}
+
+		schema := record.Schema()
+		if got := schema.Field(4); got.Name != "price" || got.Type.ID() != arrow.INT64 {
+			t.Errorf("price field = %v", got)
+		}
+		if scale, _ := schema.Field(4).Metadata.GetValue("scale"); scale != "4" {
+			t.Errorf("price scale = %q, want 4", scale)
+		}
+
+		prices := record.Column(4).(*array.Int64)
+		if got := prices.Value(0); got != 3000125 {
+			t.Errorf("price = %d, want 3000125", got)
+		}
+
+		stocks := record.Column(0).(*array.Dictionary)
+		if got := stocks.Dictionary().(*array.String).Value(stocks.GetValueIndex(0)); got != "AAPL" && got != "MSFT" && got != "ERIC" {
+			t.Errorf("stock = %q", got)
+		}
+
+		timestamps := record.Column(1).(*array.Time64)
+		if got := time.Duration(timestamps.Value(0)); got < 9*time.Hour {
+			t.Errorf("timestamp = %v", got)
+		}
+
+		return nil
+	}, WithBatchSize(4), WithAllocator(mem))
+
+	for _, m := range orderAdds() {
+		if err := e.Write(m); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := e.Write(itch.SystemEvent{EventCode: itch.EVENT_START_MESSAGES}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if rows["OrderAdd"] != 10 || rows["SystemEvent"] != 1 {
+		t.Errorf("rows = %v", rows)
+	}
+
+	// 10 order adds in batches of 4 and a single system event
+	if batches != 4 {
+		t.Errorf("batches = %d, want 4", batches)
+	}
+}
+
+func TestParquetExporter(t *testing.T) {
+	dir := t.TempDir()
+
+	e := NewParquetExporter(dir, WithBatchSize(3))
+
+	mwcb := itch.MwcbLevel{LevelOne: udecimal.MustParse("2967.72"), LevelTwo: udecimal.MustParse("2775.02"), LevelThree: udecimal.MustParse("2582.32")}
+	for _, m := range append(orderAdds(), mwcb) {
+		if err := e.Write(m); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		rows    int64
+		column  int
+		first   int64
+		columns int
+	}{
+		{"OrderAdd", 10, 4, 3000125, 8},
+		{"MwcbLevel", 1, 3, 296772000000, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(filepath.Join(dir, tt.name+".parquet"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			reader, err := file.NewParquetReader(f)
+			if err != nil {
+				t.Fatalf("NewParquetReader() error = %v", err)
+			}
+			defer reader.Close()
+
+			fr, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			table, err := fr.ReadTable(context.Background())
+			if err != nil {
+				t.Fatalf("ReadTable() error = %v", err)
+			}
+			defer table.Release()
+
+			if table.NumRows() != tt.rows || int(table.NumCols()) != tt.columns {
+				t.Errorf("table has %d rows and %d columns, want %d and %d", table.NumRows(), table.NumCols(), tt.rows, tt.columns)
+			}
+
+			prices := table.Column(tt.column).Data().Chunk(0).(*array.Int64)
+			if got := prices.Value(0); got != tt.first {
+				t.Errorf("price = %d, want %d", got, tt.first)
+			}
+		})
+	}
+}
+
+func TestExporter_Codes(t *testing.T) {
+	codes := map[string]string{}
+
+	e := NewExporter(func(name string, record arrow.Record) error {
+		for i, field := range record.Schema().Fields() {
+			if field.Type.ID() != arrow.DICTIONARY {
+				continue
+			}
+
+			column := record.Column(i).(*array.Dictionary)
+			codes[name+"."+field.Name] = column.Dictionary().(*array.String).Value(column.GetValueIndex(0))
+		}
+
+		return nil
+	})
+
+	messages := []itch.ItchMessage{
+		itch.MwcbStatus{BreachedLevel: itch.BREACHED_LEVEL_2},
+		itch.Noii{Stock: "AAPL", ImbalanceDirection: itch.IMBALANCE_BUY, CrossType: itch.CROSS_TYPE_NASDAQ_OPEN, VariationIndicator: itch.VARIATION_10_PERCENT},
+		itch.StockTradingAction{Stock: "AAPL", TradingState: itch.STATE_TRADING, Reserved: ' '},
+	}
+	for _, m := range messages {
+		if err := e.Write(m); err != nil {
+			t.Fatalf("Write(%T) error = %v", m, err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := map[string]string{
+		"MwcbStatus.breachedLevel":        "2",
+		"Noii.stock":                      "AAPL",
+		"Noii.imbalanceDirection":         "B",
+		"Noii.crossType":                  "O",
+		"Noii.variationIndicator":         "A",
+		"StockTradingAction.stock":        "AAPL",
+		"StockTradingAction.reason":       "",
+		"StockTradingAction.tradingState": "T",
+		"StockTradingAction.reserved":     " ",
+	}
+	if !cmp.Equal(codes, want) {
+		t.Errorf("%v", cmp.Diff(want, codes))
+	}
+}
+
+// extendedMessage is a message with a field type the Exporter doesn't support
+type extendedMessage struct {
+	itch.SystemEvent
+}
+
+func TestExporter_Pointer(t *testing.T) {
+	rows := map[string]int64{}
+
+	e := NewExporter(func(name string, record arrow.Record) error {
+		rows[name] += record.NumRows()
+		return nil
+	})
+
+	order := orderAdds()[0].(itch.OrderAdd)
+	for _, m := range []itch.ItchMessage{order, &order} {
+		if err := e.Write(m); err != nil {
+			t.Fatalf("Write(%T) error = %v", m, err)
+		}
+	}
+
+	var nilOrder *itch.OrderAdd
+	for _, m := range []itch.ItchMessage{nil, nilOrder, extendedMessage{}} {
+		if err := e.Write(m); err == nil {
+			t.Errorf("Write(%T) expected error", m)
+		}
+		if _, err := Schema(m); err == nil {
+			t.Errorf("Schema(%T) expected error", m)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if rows["OrderAdd"] != 2 || len(rows) != 1 {
+		t.Errorf("rows = %v", rows)
+	}
+}
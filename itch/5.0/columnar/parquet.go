@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package columnar
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// NewParquetExporter creates an Exporter writing a Parquet file for each message type to dir, named after the
+// message's Go type such as OrderAdd.parquet. Each record batch becomes a row group. Closing the Exporter
+// finishes the files
+func NewParquetExporter(dir string, opts ...Option) *Exporter {
+	writers := make(map[string]*pqarrow.FileWriter)
+
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Zstd), parquet.WithDictionaryDefault(true))
+	arrowProps := pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema())
+
+	e := NewExporter(func(name string, record arrow.Record) error {
+		w, ok := writers[name]
+		if !ok {
+			file, err := os.Create(filepath.Join(dir, name+".parquet"))
+			if err != nil {
+				return err
+			}
+
+			w, err = pqarrow.NewFileWriter(record.Schema(), file, props, arrowProps)
+			if err != nil {
+				file.Close()
+				return err
+			}
+			writers[name] = w
+		}
+
+		return w.Write(record)
+	}, opts...)
+
+	e.close = func() error {
+		allErrs := error(nil)
+
+		// Closing a FileWriter writes the footer and closes the file
+		for _, w := range writers {
+			allErrs = errors.Join(allErrs, w.Close())
+		}
+		clear(writers)
+
+		return allErrs
+	}
+
+	return e
+}
@@ -4,18 +4,57 @@
 
 package itch
 
+import "time"
+
 const (
 	OneGB = 1 << (10 * 3)
 )
 
+// ErrorPolicy decides what happens when a message fails to parse
+type ErrorPolicy uint8
+
+const (
+	// Skip the message and report its error. Functions returning every error join them together
+	ERROR_POLICY_COLLECT ErrorPolicy = iota
+	// Stop parsing at the first message that fails
+	ERROR_POLICY_STOP
+	// Skip the message without reporting an error
+	ERROR_POLICY_SKIP
+)
+
 // Configuration contains settings for adjusting how messages are parsed
 type Configuration struct {
 	// Set which message types to parse
 	MessageTypes []byte
+	// Only parse messages for these stock symbols, matched to their stock locate codes by Stock Directory
+	// messages. Messages with Stock Locate 0, which apply to every stock, are still parsed
+	Symbols []string
+	// Only parse messages with a Timestamp at or after StartTime and before EndTime, as time since midnight.
+	// A zero EndTime has no upper bound
+	StartTime time.Duration
+	EndTime   time.Duration
+	// Filter is called with the header of each message left after the other filters and the message is only
+	// parsed if it returns true
+	Filter func(Header) bool
 	// Maximum amount of messages to parse
 	MaxMessages int
 	// Set buffer size for io.reader when using ParseFile
 	ReadBufferSize uint64
 	// Whether the ITCH messages are prefixed by two byte length field (e.g. a sample file from NASDAQ FTP server)
 	LengthFieldPrefixed bool
+	// FeedState is populated with the Stock Directory and Market Participant Position messages that are read,
+	// including those not selected by MessageTypes. If nil a Decoder creates its own, see Decoder.State
+	FeedState *FeedState
+	// What to do when a message fails to parse. Errors are reported as a DecodeError
+	ErrorPolicy ErrorPolicy
+	// Decompress gzip compressed input on a separate goroutine, ahead of parsing. A gzip stream can only be
+	// decompressed in order, so decompression is still single threaded but overlaps with parsing
+	DecompressAhead bool
+	// Date of the feed, used to convert message timestamps to a time.Time. When opening a file it is inferred
+	// from the filename if not set, see Decoder.TradingDate
+	TradingDate TradingDate
+	// Number of goroutines parsing messages in ParseFileParallel and DecodeParallel. Defaults to GOMAXPROCS
+	Workers int
+	// Deliver messages from ParseFileParallel and DecodeParallel as soon as they are parsed rather than in their original order
+	Unordered bool
 }
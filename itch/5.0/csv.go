@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/quagmt/udecimal"
+)
+
+// CSVExporter writes ITCH messages as CSV, with a separate CSV stream for each message type. The first row
+// of each stream is a header holding the field names, which are the same as the JSON field names.
+//
+// Timestamps are nanoseconds since midnight, prices are decimals and enums are their ITCH code, see MessageFields.
+type CSVExporter struct {
+	create  func(messageType uint8, name string) (io.Writer, error)
+	tables  map[uint8]*csvTable
+	closers []io.Closer
+}
+
+// csvTable is the CSV stream of a single message type
+type csvTable struct {
+	writer *csv.Writer
+	fields []csvField
+	record []string
+}
+
+// csvField formats one struct field of a message
+type csvField struct {
+	index  int
+	format func(v reflect.Value) string
+}
+
+// NewCSVExporter creates a CSVExporter that calls create the first time it sees each message type to get the
+// writer for its CSV stream. name is the name of the message's Go type, such as OrderAdd
+func NewCSVExporter(create func(messageType uint8, name string) (io.Writer, error)) *CSVExporter {
+	return &CSVExporter{
+		create: create,
+		tables: make(map[uint8]*csvTable),
+	}
+}
+
+// NewCSVFileExporter creates a CSVExporter writing a file for each message type to dir, named after the
+// message's Go type such as OrderAdd.csv. Closing the CSVExporter closes the files
+func NewCSVFileExporter(dir string) *CSVExporter {
+	e := NewCSVExporter(nil)
+	e.create = func(_ uint8, name string) (io.Writer, error) {
+		file, err := os.Create(filepath.Join(dir, name+".csv"))
+		if err != nil {
+			return nil, err
+		}
+
+		e.closers = append(e.closers, file)
+		return file, nil
+	}
+
+	return e
+}
+
+// Write adds a message to the CSV stream of its type, writing the header row first if it is the first message of
+// that type. Pointers to messages are written as their value
+func (e *CSVExporter) Write(msg ItchMessage) error {
+	v, err := MessageValue(msg)
+	if err != nil {
+		return err
+	}
+	msg = v.Interface().(ItchMessage)
+
+	table, ok := e.tables[msg.Type()]
+	if !ok {
+		if table, err = e.newTable(msg); err != nil {
+			return err
+		}
+		e.tables[msg.Type()] = table
+	}
+
+	for i, f := range table.fields {
+		table.record[i] = f.format(v.Field(f.index))
+	}
+
+	return table.writer.Write(table.record)
+}
+
+func (e *CSVExporter) newTable(msg ItchMessage) (*csvTable, error) {
+	t := reflect.TypeOf(msg)
+
+	table := &csvTable{}
+	header := []string{}
+
+	for _, field := range MessageFields(t) {
+		format := csvFormat(field.Kind)
+		if format == nil {
+			return nil, fmt.Errorf("csv: unsupported field %s.%s of type %s", t.Name(), field.Name, field.Type)
+		}
+
+		header = append(header, field.Name)
+		table.fields = append(table.fields, csvField{index: field.Index, format: format})
+	}
+	table.record = make([]string, len(header))
+
+	w, err := e.create(msg.Type(), t.Name())
+	if err != nil {
+		return nil, err
+	}
+	table.writer = csv.NewWriter(w)
+
+	if err := table.writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// csvFormat returns how to format a field of the given kind, or nil if the kind is unknown
+func csvFormat(kind FieldKind) func(v reflect.Value) string {
+	switch kind {
+	case FIELD_KIND_TIMESTAMP:
+		return func(v reflect.Value) string {
+			return strconv.FormatInt(v.Int(), 10)
+		}
+	case FIELD_KIND_PRICE:
+		return func(v reflect.Value) string {
+			return v.Interface().(udecimal.Decimal).String()
+		}
+	case FIELD_KIND_CODE:
+		return func(v reflect.Value) string {
+			return CodeString(uint8(v.Uint()))
+		}
+	case FIELD_KIND_STRING:
+		return reflect.Value.String
+	case FIELD_KIND_BOOL:
+		return func(v reflect.Value) string {
+			return strconv.FormatBool(v.Bool())
+		}
+	case FIELD_KIND_INTEGER:
+		return func(v reflect.Value) string {
+			return strconv.FormatUint(v.Uint(), 10)
+		}
+	}
+
+	return nil
+}
+
+// Flush writes any buffered rows to the underlying writers
+func (e *CSVExporter) Flush() error {
+	allErrs := error(nil)
+
+	for _, table := range e.tables {
+		table.writer.Flush()
+		allErrs = errors.Join(allErrs, table.writer.Error())
+	}
+
+	return allErrs
+}
+
+// Close flushes any buffered rows and closes the files created by NewCSVFileExporter
+func (e *CSVExporter) Close() error {
+	allErrs := e.Flush()
+
+	for _, closer := range e.closers {
+		allErrs = errors.Join(allErrs, closer.Close())
+	}
+	e.closers = nil
+
+	return allErrs
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCSVExporter(t *testing.T) {
+	outputs := map[string]*bytes.Buffer{}
+
+	e := NewCSVExporter(func(_ uint8, name string) (io.Writer, error) {
+		outputs[name] = &bytes.Buffer{}
+		return outputs[name], nil
+	})
+
+	for _, m := range append(allMessages(), testMessages()...) {
+		if err := e.Write(m); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if len(outputs) != len(allMessages()) {
+		t.Errorf("got %d CSV streams, want %d", len(outputs), len(allMessages()))
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"OrderAdd", "stock,timestamp,reference,shares,price,stockLocate,trackingNumber,orderIndicator\n" +
+			"AAPL,36000000000000,1,100,300.01,13,10,B\n" +
+			"ERIC,14400000000000,13662,6000,7.93,2528,0,B\n"},
+		{"SystemEvent", "timestamp,stockLocate,trackingNumber,eventCode\n" +
+			"10800000000000,0,1,S\n" +
+			"10800000000000,0,0,O\n" +
+			"72000000000000,0,0,C\n"},
+		{"OrderExecutedPrice", "timestamp,reference,matchNumber,shares,executionPrice,stockLocate,trackingNumber,printable\n" +
+			"39600000000000,1,2,10,300.03,13,13,true\n"},
+		{"MwcbStatus", "timestamp,stockLocate,trackingNumber,breachedLevel\n" +
+			"14400000000000,0,6,1\n"},
+		{"Noii", "stock,timestamp,pairedShares,imbalanceShares,farPrice,nearPrice,currentPrice,stockLocate,trackingNumber,imbalanceDirection,crossType,variationIndicator\n" +
+			"AAPL,54000000000000,1000,500,300.07,300.08,300.09,13,20,B,C,A\n"},
+		{"StockTradingAction", "stock,reason,timestamp,stockLocate,trackingNumber,tradingState,reserved\n" +
+			"AAPL,T1,14400000000000,13,2,T,\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputs[tt.name].String(); got != tt.want {
+				t.Errorf("CSV = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVExporter_Pointer(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewCSVExporter(func(uint8, string) (io.Writer, error) {
+		return &buf, nil
+	})
+
+	order := testMessages()[1].(OrderAdd)
+	for _, m := range []ItchMessage{order, &order} {
+		if err := e.Write(m); err != nil {
+			t.Fatalf("Write(%T) error = %v", m, err)
+		}
+	}
+
+	var nilOrder *OrderAdd
+	if err := e.Write(nilOrder); !errors.As(err, &ErrUnsupportedMessage{}) {
+		t.Errorf("Write((*OrderAdd)(nil)) error = %v, want ErrUnsupportedMessage", err)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "stock,timestamp,reference,shares,price,stockLocate,trackingNumber,orderIndicator\n" +
+		"ERIC,14400000000000,13662,6000,7.93,2528,0,B\n" +
+		"ERIC,14400000000000,13662,6000,7.93,2528,0,B\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+}
+
+func TestCSVFileExporter(t *testing.T) {
+	dir := t.TempDir()
+
+	e := NewCSVFileExporter(dir)
+	for _, m := range testMessages() {
+		if err := e.Write(m); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for name, rows := range map[string]int{"SystemEvent": 3, "OrderAdd": 2, "OrderExecuted": 2, "OrderDelete": 2} {
+		file, err := os.Open(filepath.Join(dir, name+".csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+
+		records, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			t.Fatalf("ReadAll() error = %v", err)
+		}
+
+		if len(records) != rows {
+			t.Errorf("%s.csv has %d rows, want %d", name, len(records), rows)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"os"
+)
+
+// Decoder reads ITCH messages one at a time from an io.Reader. Unlike ParseReader it never
+// holds more than a single message in memory, so it can be used to process full-day files.
+type Decoder struct {
+	// Messages are read from reader, or directly from data if reader is nil
+	reader  *bufio.Reader
+	data    []byte
+	pos     int
+	closers []io.Closer
+	config  Configuration
+	state   *FeedState
+
+	symbols symbolTable
+	filter  messageFilter
+	buf     []byte
+	count   int
+
+	// consumed is the number of bytes read and index the number of messages read, including those not
+	// selected by MessageTypes. frameOffset and frameIndex locate the last message returned by readFrame
+	consumed    int64
+	index       int
+	frameOffset int64
+	frameIndex  int
+
+	// err is the first error that stopped decoding. Once set every call to Next returns it.
+	err error
+}
+
+// NewDecoder creates a Decoder reading from reader. If reader is not already a *bufio.Reader it is
+// wrapped in one using Configuration.ReadBufferSize.
+//
+// gzip compressed input is detected and decompressed. The Decoder should be closed when finished if
+// Configuration.DecompressAhead is set, which does not close reader itself.
+func NewDecoder(reader io.Reader, config Configuration) *Decoder {
+	d := newDecoder(config)
+	d.reader = newBufferedReader(reader, config.ReadBufferSize)
+
+	if isGzip(d.reader) {
+		gz, closer, err := newGzipReader(d.reader, config.DecompressAhead)
+		if err != nil {
+			d.err = err
+			return d
+		}
+
+		d.reader = newBufferedReader(gz, config.ReadBufferSize)
+		d.closers = append(d.closers, closer)
+	}
+
+	return d
+}
+
+// newBytesDecoder creates a Decoder reading directly from data, which may be gzip compressed
+func newBytesDecoder(data []byte, config Configuration) *Decoder {
+	if bytes.HasPrefix(data, gzipMagic) {
+		return NewDecoder(bytes.NewReader(data), config)
+	}
+
+	d := newDecoder(config)
+	d.data = data
+
+	return d
+}
+
+func newDecoder(config Configuration) *Decoder {
+	state := config.FeedState
+	if state == nil {
+		state = NewFeedState()
+	}
+
+	return &Decoder{
+		config:  config,
+		state:   state,
+		symbols: newSymbolTable(),
+		filter:  newMessageFilter(config, state),
+	}
+}
+
+// OpenFile opens an ITCH file for decoding, decompressing it if it is gzip compressed. The returned Decoder
+// should be closed when finished
+func OpenFile(path string, config Configuration) (*Decoder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := NewDecoder(file, withTradingDate(config, path))
+	d.closers = append(d.closers, file)
+
+	return d, nil
+}
+
+// Close stops any decompression and closes the underlying file if the Decoder was created with OpenFile
+func (d *Decoder) Close() error {
+	allErrs := error(nil)
+
+	for _, closer := range d.closers {
+		allErrs = errors.Join(allErrs, closer.Close())
+	}
+	d.closers = nil
+
+	return allErrs
+}
+
+// TradingDate returns the date of the feed from Configuration.TradingDate or, if it was not set, the filename
+// passed to OpenFile, OpenMapped or OpenIndexed. It is zero if the date is unknown
+func (d *Decoder) TradingDate() TradingDate {
+	return d.config.TradingDate
+}
+
+// State returns the FeedState populated by the Decoder. It is Configuration.FeedState if that was set
+func (d *Decoder) State() *FeedState {
+	return d.state
+}
+
+// Next decodes and returns the next message. It returns io.EOF when there are no more messages, or
+// when Configuration.MaxMessages messages have been returned.
+//
+// If a message fails to parse then Configuration.ErrorPolicy decides what happens. By default a
+// DecodeError is returned and decoding can continue with the next call. Any other error, such as a
+// read error, stops the Decoder.
+func (d *Decoder) Next() (ItchMessage, error) {
+	for {
+		data, err := d.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := parseData(data, d.symbols)
+		if err != nil {
+			if err = d.parseError(data, err); err == nil {
+				continue
+			}
+			return nil, err
+		}
+
+		return m, nil
+	}
+}
+
+// All returns an iterator over the remaining messages. Iteration stops at the end of the input
+// or after yielding an error that stopped the Decoder.
+func (d *Decoder) All() iter.Seq2[ItchMessage, error] {
+	return func(yield func(ItchMessage, error) bool) {
+		for {
+			m, err := d.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(m, err) || d.err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the error that stopped the Decoder, such as a read error or a truncated message at the end of
+// the input. It is nil if the Decoder is still running or reached the end of the input. All does not report
+// whether the error it yielded stopped the Decoder, so callers that skip parse errors can check Err once
+// iteration ends
+func (d *Decoder) Err() error {
+	if d.err == io.EOF {
+		return nil
+	}
+
+	return d.err
+}
+
+// parseAll parses the remaining messages into memory. Any errors parsing a message will be joined together
+// and returned after parsing all messages
+func (d *Decoder) parseAll() ([]ItchMessage, error) {
+	messages := []ItchMessage{}
+
+	allErrs := error(nil)
+
+	for {
+		data, err := d.readFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			allErrs = errors.Join(allErrs, err)
+			if d.err != nil {
+				return messages, allErrs
+			}
+			continue
+		}
+
+		m, err := parseData(data, d.symbols)
+		if err != nil {
+			allErrs = errors.Join(allErrs, d.parseError(data, err))
+			if d.err != nil {
+				return messages, allErrs
+			}
+			continue
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages, allErrs
+}
+
+// parseError applies Configuration.ErrorPolicy to an error parsing the last message returned by readFrame.
+// It returns the error wrapped in a DecodeError, or nil if the error should be ignored
+func (d *Decoder) parseError(data []byte, err error) error {
+	return d.applyPolicy(NewDecodeError(d.frameOffset, d.frameIndex, data[0], err))
+}
+
+// applyPolicy applies Configuration.ErrorPolicy to a DecodeError, returning nil if it should be ignored
+func (d *Decoder) applyPolicy(err DecodeError) error {
+	switch d.config.ErrorPolicy {
+	case ERROR_POLICY_SKIP:
+		return nil
+	case ERROR_POLICY_STOP:
+		return d.stop(err)
+	}
+
+	return err
+}
+
+// readFrame returns the next wanted ITCH message without its length prefix. The returned slice is
+// only valid until the next call to readFrame and must not be modified.
+//
+// Unknown or corrupt data is skipped and reported as a DecodeError. These errors do not stop the Decoder
+// unless Configuration.ErrorPolicy is ERROR_POLICY_STOP, so the caller can check d.err to tell them apart
+// from errors that do, such as the input being truncated.
+func (d *Decoder) readFrame() ([]byte, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	for {
+		if d.config.MaxMessages > 0 && d.count >= d.config.MaxMessages {
+			d.err = io.EOF
+			return nil, d.err
+		}
+
+		var msgLength int
+
+		offset := d.consumed
+
+		if d.config.LengthFieldPrefixed {
+			msgLengthBuffer, err := d.peek(2)
+			if err != nil {
+				if err == io.EOF && len(msgLengthBuffer) > 0 {
+					err = NewDecodeError(offset, d.index, 0, NewTruncatedMessage(2))
+				}
+				return nil, d.stop(err)
+			}
+
+			msgLength = int(uint16(msgLengthBuffer[1]) | uint16(msgLengthBuffer[0])<<8)
+
+			if err := d.discard(2); err != nil {
+				return nil, d.stop(err)
+			}
+			d.consumed += 2
+
+			// An empty frame holds no message, so skip over its length field
+			if msgLength == 0 {
+				if err := d.applyPolicy(NewDecodeError(offset, d.index, 0, NewInvalidPacketSize(1, 0))); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		} else {
+			msgTypeBuffer, err := d.peek(1)
+			if err != nil {
+				return nil, d.stop(err)
+			}
+
+			msgLength = getMessageSize(msgTypeBuffer[0])
+
+			// Without a length field the size of an unknown message can't be known, so skip ahead to where
+			// the data looks like messages again
+			if msgLength == 0 {
+				msgType := msgTypeBuffer[0]
+
+				skipped, err := d.resync()
+				d.consumed += int64(skipped)
+				if err != nil && err != io.EOF {
+					return nil, d.stop(err)
+				}
+
+				if err := d.applyPolicy(NewDecodeError(offset, d.index, msgType, NewSkippedData(skipped))); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		data, err := d.read(msgLength)
+		if err != nil {
+			// The message is incomplete, or missing entirely after its length field
+			if err == io.ErrUnexpectedEOF || err == io.EOF {
+				msgType := uint8(0)
+				if remaining, _ := d.peek(1); len(remaining) > 0 {
+					msgType = remaining[0]
+				}
+				err = NewDecodeError(offset, d.index, msgType, NewTruncatedMessage(msgLength))
+			}
+			return nil, d.stop(err)
+		}
+
+		d.consumed += int64(msgLength)
+		d.frameOffset = offset
+		d.frameIndex = d.index
+		d.index++
+
+		d.state.applyData(data, d.symbols)
+
+		// Only parse messages selected by MessageTypes, Symbols, the time window and Filter
+		if !d.filter.selects(data, d.symbols) {
+			continue
+		}
+
+		d.count++
+
+		return data, nil
+	}
+}
+
+// resync skips data until it looks like the start of a message again, which is a known message type followed
+// by either another known message type or the end of the input. It returns the number of bytes skipped
+func (d *Decoder) resync() (int, error) {
+	skipped := 0
+
+	for {
+		if err := d.discard(1); err != nil {
+			return skipped, err
+		}
+		skipped++
+
+		buf, err := d.peek(1)
+		if err != nil {
+			return skipped, err
+		}
+
+		size := getMessageSize(buf[0])
+		if size == 0 {
+			continue
+		}
+
+		next, err := d.peek(size + 1)
+		if err == io.EOF && len(next) == size {
+			return skipped, nil
+		}
+		if err == nil && getMessageSize(next[size]) != 0 {
+			return skipped, nil
+		}
+		if err != nil && err != io.EOF {
+			return skipped, err
+		}
+	}
+}
+
+// peek returns the next n bytes without consuming them. Like bufio.Reader.Peek it returns io.EOF if
+// fewer than n bytes remain
+func (d *Decoder) peek(n int) ([]byte, error) {
+	if d.reader != nil {
+		return d.reader.Peek(n)
+	}
+
+	if remaining := d.data[d.pos:]; len(remaining) < n {
+		return remaining, io.EOF
+	}
+
+	return d.data[d.pos : d.pos+n], nil
+}
+
+func (d *Decoder) discard(n int) error {
+	if d.reader != nil {
+		_, err := d.reader.Discard(n)
+		return err
+	}
+
+	d.pos = min(d.pos+n, len(d.data))
+
+	return nil
+}
+
+// read consumes the next n bytes. Where possible the returned slice points into the input data or the
+// bufio.Reader's buffer rather than being copied, since messages are decoded without modifying their data
+func (d *Decoder) read(n int) ([]byte, error) {
+	if d.reader == nil {
+		data, err := d.peek(n)
+		if err != nil {
+			if len(data) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		d.pos += n
+		return data, nil
+	}
+
+	if n <= d.reader.Size() {
+		data, err := d.reader.Peek(n)
+		if err != nil {
+			if err == io.EOF && len(data) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		_, err = d.reader.Discard(n)
+		return data, err
+	}
+
+	if cap(d.buf) < n {
+		d.buf = make([]byte, n)
+	}
+	data := d.buf[:n]
+
+	_, err := io.ReadFull(d.reader, data)
+
+	return data, err
+}
+
+func (d *Decoder) stop(err error) error {
+	d.err = err
+	return err
+}
+
+func newBufferedReader(reader io.Reader, size uint64) *bufio.Reader {
+	if r, ok := reader.(*bufio.Reader); ok {
+		return r
+	}
+
+	if size > 0 {
+		return bufio.NewReaderSize(reader, int(size))
+	}
+
+	return bufio.NewReader(reader)
+}
@@ -0,0 +1,500 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quagmt/udecimal"
+)
+
+func testMessages() []ItchMessage {
+	return []ItchMessage{
+		SystemEvent{Timestamp: 3 * time.Hour, EventCode: EVENT_START_MESSAGES},
+		OrderAdd{
+			StockLocate:    2528,
+			Timestamp:      4 * time.Hour,
+			Reference:      13662,
+			OrderIndicator: ORDER_INDICATOR_BUY,
+			Shares:         6000,
+			Stock:          "ERIC",
+			Price:          udecimal.MustParse("7.93"),
+		},
+		OrderExecuted{StockLocate: 2528, Timestamp: 5 * time.Hour, Reference: 13662, Shares: 100, MatchNumber: 1},
+		OrderDelete{StockLocate: 2528, Timestamp: 6 * time.Hour, Reference: 13662},
+		SystemEvent{Timestamp: 20 * time.Hour, EventCode: EVENT_END_MESSAGES},
+	}
+}
+
+// allMessages returns one message of every type with every field set
+func allMessages() []ItchMessage {
+	return []ItchMessage{
+		SystemEvent{Timestamp: 3 * time.Hour, TrackingNumber: 1, EventCode: EVENT_START_HOURS},
+		StockDirectory{
+			Timestamp:                   3 * time.Hour,
+			Stock:                       "AAPL",
+			StockLocate:                 13,
+			TrackingNumber:              1,
+			RoundLotSize:                100,
+			IssueSubType:                ICS_NOT_APPLICABLE,
+			IssueClassification:         IC_COMMON_STOCK,
+			RoundLotsOnly:               true,
+			InverseIndicator:            true,
+			Authenticity:                AUTHENTICITY_LIVE,
+			EtpLeverageFactor:           2,
+			ShortSaleThresholdIndicator: "N",
+			IpoFlag:                     "N",
+			LuldReferencePriceTier:      "1",
+			EtpFlag:                     "N",
+			MarketCategory:              MKTCTG_NASDAQ_GLOBAL_SELECT,
+			FinancialStatusIndicator:    FSI_NORMAL,
+		},
+		StockTradingAction{Stock: "AAPL", Reason: "T1", Timestamp: 4 * time.Hour, StockLocate: 13, TrackingNumber: 2, TradingState: STATE_TRADING},
+		RegSho{Stock: "AAPL", Timestamp: 4 * time.Hour, StockLocate: 13, TrackingNumber: 3, Action: REGSHO_INTRADAY_DROP},
+		ParticipantPosition{Timestamp: 4 * time.Hour, Mpid: "GSCO", Stock: "AAPL", StockLocate: 13, TrackingNumber: 4, PrimaryMM: true, Mode: MMMODE_NORMAL, State: MMSTATE_ACTIVE},
+		MwcbLevel{Timestamp: 4 * time.Hour, TrackingNumber: 5, LevelOne: udecimal.MustParse("2967.72"), LevelTwo: udecimal.MustParse("2775.02"), LevelThree: udecimal.MustParse("2582.32")},
+		MwcbStatus{Timestamp: 4 * time.Hour, TrackingNumber: 6, BreachedLevel: BREACHED_LEVEL_1},
+		IpoQuotation{StockLocate: 13, TrackingNumber: 7, Timestamp: 4 * time.Hour, Stock: "AAPL", ReleaseTime: 10 * time.Hour, Qualifier: QUALIFIER_ANTICIPATED, Price: udecimal.MustParse("22.5")},
+		LuldCollar{StockLocate: 13, TrackingNumber: 8, Timestamp: 9 * time.Hour, Stock: "AAPL", ReferencePrice: udecimal.MustParse("300"), UpperPrice: udecimal.MustParse("315"), LowerPrice: udecimal.MustParse("285"), Extension: 1},
+		OperationalHalt{Stock: "AAPL", Timestamp: 9 * time.Hour, StockLocate: 13, TrackingNumber: 9, MarketCode: MARKET_CODE_NASDAQ, HaltAction: HALT_ACTION_HALT},
+		OrderAdd{Stock: "AAPL", Timestamp: 10 * time.Hour, Reference: 1, Shares: 100, Price: udecimal.MustParse("300.01"), StockLocate: 13, TrackingNumber: 10, OrderIndicator: ORDER_INDICATOR_BUY},
+		OrderAddAttributed{Stock: "AAPL", Attribution: "GSCO", Timestamp: 10 * time.Hour, Reference: 2, Shares: 200, Price: udecimal.MustParse("300.02"), StockLocate: 13, TrackingNumber: 11, OrderIndicator: ORDER_INDICATOR_SELL},
+		OrderExecuted{Timestamp: 11 * time.Hour, Reference: 1, MatchNumber: 1, Shares: 10, StockLocate: 13, TrackingNumber: 12},
+		OrderExecutedPrice{Timestamp: 11 * time.Hour, Reference: 1, MatchNumber: 2, Shares: 10, ExecutionPrice: udecimal.MustParse("300.03"), StockLocate: 13, TrackingNumber: 13, Printable: true},
+		OrderCancel{Timestamp: 11 * time.Hour, Reference: 1, Shares: 10, StockLocate: 13, TrackingNumber: 14},
+		OrderDelete{StockLocate: 13, TrackingNumber: 15, Timestamp: 11 * time.Hour, Reference: 1},
+		OrderReplace{StockLocate: 13, TrackingNumber: 16, Timestamp: 11 * time.Hour, OriginalReference: 2, NewReference: 3, Shares: 300, Price: udecimal.MustParse("300.04")},
+		TradeNonCross{Stock: "AAPL", Timestamp: 12 * time.Hour, Reference: 0, MatchNumber: 3, Shares: 100, Price: udecimal.MustParse("300.05"), StockLocate: 13, TrackingNumber: 17, OrderIndicator: ORDER_INDICATOR_BUY},
+		TradeCross{Stock: "AAPL", Timestamp: 16 * time.Hour, MatchNumber: 4, Shares: 1000, CrossPrice: udecimal.MustParse("300.06"), StockLocate: 13, TrackingNumber: 18, CrossType: CROSS_TYPE_NASDAQ_CLOSE},
+		TradeBroken{StockLocate: 13, TrackingNumber: 19, Timestamp: 16 * time.Hour, MatchNumber: 3},
+		Noii{Stock: "AAPL", Timestamp: 15 * time.Hour, PairedShares: 1000, ImbalanceShares: 500, FarPrice: udecimal.MustParse("300.07"), NearPrice: udecimal.MustParse("300.08"), CurrentPrice: udecimal.MustParse("300.09"), StockLocate: 13, TrackingNumber: 20, ImbalanceDirection: IMBALANCE_BUY, CrossType: CROSS_TYPE_NASDAQ_CLOSE, VariationIndicator: VARIATION_10_PERCENT},
+		Rpii{Stock: "AAPL", Timestamp: 15 * time.Hour, StockLocate: 13, TrackingNumber: 21, InterestFlag: RPI_INTEREST_BOTH},
+	}
+}
+
+func encodeMessages(messages []ItchMessage, lengthFieldPrefixed bool) []byte {
+	var buf bytes.Buffer
+
+	for _, m := range messages {
+		data := m.Bytes()
+		if lengthFieldPrefixed {
+			buf.Write(binary.BigEndian.AppendUint16(nil, uint16(len(data))))
+		}
+		buf.Write(data)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecoder_Next(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Configuration
+		want   []ItchMessage
+	}{
+		{
+			name:   "raw",
+			config: Configuration{},
+			want:   testMessages(),
+		},
+		{
+			name:   "length field prefixed",
+			config: Configuration{LengthFieldPrefixed: true},
+			want:   testMessages(),
+		},
+		{
+			name:   "max messages",
+			config: Configuration{MaxMessages: 2},
+			want:   testMessages()[:2],
+		},
+		{
+			name:   "message types",
+			config: Configuration{MessageTypes: []byte{MESSAGE_SYSTEM_EVENT}},
+			want:   []ItchMessage{testMessages()[0], testMessages()[4]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodeMessages(testMessages(), tt.config.LengthFieldPrefixed)
+			d := NewDecoder(bytes.NewReader(data), tt.config)
+
+			got := []ItchMessage{}
+			for {
+				m, err := d.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Decoder.Next() error = %v", err)
+				}
+				got = append(got, m)
+			}
+
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("%v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestDecoder_All(t *testing.T) {
+	data := encodeMessages(testMessages(), false)
+	d := NewDecoder(bytes.NewReader(data), Configuration{})
+
+	got := []ItchMessage{}
+	for m, err := range d.All() {
+		if err != nil {
+			t.Fatalf("Decoder.All() error = %v", err)
+		}
+		got = append(got, m)
+	}
+
+	if !cmp.Equal(got, testMessages()) {
+		t.Errorf("%v", cmp.Diff(testMessages(), got))
+	}
+	if err := d.Err(); err != nil {
+		t.Errorf("Decoder.Err() = %v at the end of the input, want nil", err)
+	}
+}
+
+func TestDecoder_AllTruncated(t *testing.T) {
+	data := encodeMessages(testMessages(), false)
+	d := NewDecoder(bytes.NewReader(data[:len(data)-1]), Configuration{})
+
+	var messages, errs int
+	for _, err := range d.All() {
+		if err != nil {
+			errs++
+			continue
+		}
+		messages++
+	}
+
+	if messages != 4 || errs != 1 {
+		t.Errorf("got %d messages and %d errors, want 4 messages and 1 error", messages, errs)
+	}
+
+	var truncated ErrTruncatedMessage
+	if err := d.Err(); !errors.As(err, &truncated) {
+		t.Errorf("Decoder.Err() = %v, want ErrTruncatedMessage", err)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "01302020.NASDAQ_ITCH50")
+	if err := os.WriteFile(path, encodeMessages(testMessages(), true), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenFile(path, Configuration{LengthFieldPrefixed: true})
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer d.Close()
+
+	got := []ItchMessage{}
+	for m, err := range d.All() {
+		if err != nil {
+			t.Fatalf("Decoder.All() error = %v", err)
+		}
+		got = append(got, m)
+	}
+
+	if !cmp.Equal(got, testMessages()) {
+		t.Errorf("%v", cmp.Diff(testMessages(), got))
+	}
+}
+
+func TestParse_DoesNotModifyData(t *testing.T) {
+	for _, m := range allMessages() {
+		data := m.Bytes()
+		original := bytes.Clone(data)
+
+		got, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parse(%c) error = %v", m.Type(), err)
+		}
+
+		if !bytes.Equal(data, original) {
+			t.Errorf("Parse(%c) modified data: got %v, want %v", m.Type(), data, original)
+		}
+		if !cmp.Equal(got, m) {
+			t.Errorf("%v", cmp.Diff(m, got))
+		}
+	}
+}
+
+func TestDecodeTo_ZeroAllocations(t *testing.T) {
+	symbols := newSymbolTable()
+	handler := NopHandler{}
+
+	for _, m := range allMessages() {
+		data := m.Bytes()
+
+		// The first decode of a symbol allocates its string
+		if err := dispatchData(data, handler, symbols); err != nil {
+			t.Fatalf("dispatchData(%c) error = %v", m.Type(), err)
+		}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = dispatchData(data, handler, symbols)
+		})
+		if allocs != 0 {
+			t.Errorf("dispatchData(%c) allocations = %v, want 0", m.Type(), allocs)
+		}
+	}
+}
+
+// benchmarkData returns about 1MiB of length-prefixed messages and the number of messages it contains
+func benchmarkData(b *testing.B) ([]byte, int) {
+	b.Helper()
+
+	messages := allMessages()
+	encoded := encodeMessages(messages, true)
+
+	var buf bytes.Buffer
+	count := 0
+	for buf.Len() < 1<<20 {
+		buf.Write(encoded)
+		count += len(messages)
+	}
+
+	return buf.Bytes(), count
+}
+
+func reportMessageRate(b *testing.B, data []byte, count int) {
+	b.StopTimer()
+	b.ReportMetric(float64(count*b.N)/b.Elapsed().Seconds(), "msgs/s")
+	b.SetBytes(int64(len(data)))
+}
+
+func BenchmarkDecodeTo(b *testing.B) {
+	data, count := benchmarkData(b)
+	config := Configuration{LengthFieldPrefixed: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := DecodeTo(bytes.NewReader(data), config, NopHandler{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	reportMessageRate(b, data, count)
+}
+
+func BenchmarkDecoder_Next(b *testing.B) {
+	data, count := benchmarkData(b)
+	config := Configuration{LengthFieldPrefixed: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		d := NewDecoder(bytes.NewReader(data), config)
+		for _, err := range d.All() {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	reportMessageRate(b, data, count)
+}
+
+func BenchmarkParseMany(b *testing.B) {
+	data, count := benchmarkData(b)
+	config := Configuration{LengthFieldPrefixed: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseMany(data, config); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	reportMessageRate(b, data, count)
+}
+
+func TestDecodeError_Policy(t *testing.T) {
+	data := encodeMessages(testMessages(), true)
+
+	// Change the type of the third message so it fails to parse. It starts after a System Event and Order Add
+	offset := 2 + systemEventSize + 2 + orderAddSize
+	data[offset+2] = 'Z'
+
+	wantErr := NewDecodeError(int64(offset), 2, 'Z', NewInvalidPacketType('Z'))
+
+	tests := []struct {
+		name         string
+		config       Configuration
+		wantMessages []ItchMessage
+		wantErr      bool
+	}{
+		{"collect", Configuration{LengthFieldPrefixed: true}, slices.Delete(testMessages(), 2, 3), true},
+		{"stop", Configuration{LengthFieldPrefixed: true, ErrorPolicy: ERROR_POLICY_STOP}, testMessages()[:2], true},
+		{"skip", Configuration{LengthFieldPrefixed: true, ErrorPolicy: ERROR_POLICY_SKIP}, slices.Delete(testMessages(), 2, 3), false},
+		{"filtered", Configuration{LengthFieldPrefixed: true, MessageTypes: []byte{'Z'}}, []ItchMessage{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMany(data, tt.config)
+			if !cmp.Equal(got, tt.wantMessages) {
+				t.Errorf("%v", cmp.Diff(tt.wantMessages, got))
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("ParseMany() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var decodeErr DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("ParseMany() error = %v, want DecodeError", err)
+			}
+			if decodeErr.Offset != wantErr.Offset || decodeErr.Index != wantErr.Index || decodeErr.MessageType != wantErr.MessageType {
+				t.Errorf("ParseMany() error = %v, want %v", decodeErr, wantErr)
+			}
+
+			var typeErr ErrInvalidPacketType
+			if !errors.As(err, &typeErr) {
+				t.Errorf("ParseMany() error = %v, want it to wrap ErrInvalidPacketType", err)
+			}
+		})
+	}
+}
+
+func TestDecoder_NextErrorPolicyStop(t *testing.T) {
+	data := encodeMessages(testMessages(), true)
+	data[2+systemEventSize+2] = 'Z'
+
+	d := NewDecoder(bytes.NewReader(data), Configuration{LengthFieldPrefixed: true, ErrorPolicy: ERROR_POLICY_STOP})
+
+	if _, err := d.Next(); err != nil {
+		t.Fatalf("Decoder.Next() error = %v", err)
+	}
+
+	_, err := d.Next()
+
+	var decodeErr DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Offset != 2+systemEventSize || decodeErr.Index != 1 {
+		t.Fatalf("Decoder.Next() error = %v, want DecodeError at offset %d", err, 2+systemEventSize)
+	}
+
+	// The Decoder has stopped
+	if _, err := d.Next(); !errors.As(err, &decodeErr) {
+		t.Errorf("Decoder.Next() error = %v, want the same DecodeError", err)
+	}
+}
+
+func TestParseMany_UnknownMessages(t *testing.T) {
+	messages := testMessages()
+
+	prefixed := encodeMessages(messages[:2], true)
+	unknownOffset := len(prefixed)
+	prefixed = append(prefixed, 0, 5, 'Z', 1, 2, 3, 4)
+	prefixed = append(prefixed, 0, 0)
+	prefixed = append(prefixed, encodeMessages(messages[2:], true)...)
+
+	raw := encodeMessages(messages[:2], false)
+	garbageOffset := len(raw)
+	raw = append(raw, 0, 0xff, '!', 0x7f, 0)
+	raw = append(raw, encodeMessages(messages[2:], false)...)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		config     Configuration
+		wantErrs   int
+		wantOffset int
+		wantType   uint8
+	}{
+		{"length prefixed", prefixed, Configuration{LengthFieldPrefixed: true}, 2, unknownOffset, 'Z'},
+		{"raw", raw, Configuration{}, 1, garbageOffset, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMany(tt.data, tt.config)
+			if !cmp.Equal(got, messages) {
+				t.Errorf("%v", cmp.Diff(messages, got))
+			}
+
+			errs := err.(interface{ Unwrap() []error }).Unwrap()
+			if len(errs) != tt.wantErrs {
+				t.Errorf("ParseMany() errors = %v, want %d errors", errs, tt.wantErrs)
+			}
+
+			var decodeErr DecodeError
+			if !errors.As(errs[0], &decodeErr) || decodeErr.Offset != int64(tt.wantOffset) || decodeErr.MessageType != tt.wantType || decodeErr.Index != 2 {
+				t.Errorf("ParseMany() error = %v, want DecodeError at offset %d", errs[0], tt.wantOffset)
+			}
+		})
+	}
+
+	var skipped ErrSkippedData
+	if _, err := ParseMany(raw, Configuration{}); !errors.As(err, &skipped) || skipped.Error() != "unknown packet type, skipped 5 bytes" {
+		t.Errorf("ParseMany() error = %v, want ErrSkippedData", err)
+	}
+}
+
+func TestParseMany_Truncated(t *testing.T) {
+	messages := testMessages()
+
+	for _, prefixed := range []bool{true, false} {
+		data := encodeMessages(messages, prefixed)
+
+		// ends holds the end of each message in data
+		ends := []int{}
+		end := 0
+		for _, m := range messages {
+			end += len(m.Bytes())
+			if prefixed {
+				end += 2
+			}
+			ends = append(ends, end)
+		}
+
+		for cut := 0; cut < len(data); cut++ {
+			complete := 0
+			for complete < len(ends) && ends[complete] <= cut {
+				complete++
+			}
+			boundary := cut == 0 || slices.Contains(ends, cut)
+
+			got, err := ParseMany(data[:cut], Configuration{LengthFieldPrefixed: prefixed})
+
+			if !cmp.Equal(got, messages[:complete]) {
+				t.Errorf("ParseMany(prefixed=%v, cut=%d) = %v, want %v", prefixed, cut, got, messages[:complete])
+			}
+
+			if boundary && err != nil {
+				t.Errorf("ParseMany(prefixed=%v, cut=%d) error = %v, want nil", prefixed, cut, err)
+			}
+			if !boundary && !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("ParseMany(prefixed=%v, cut=%d) error = %v, want truncation error", prefixed, cut, err)
+			}
+		}
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	if _, err := Parse(nil); err == nil {
+		t.Errorf("Parse(nil) expected error")
+	}
+}
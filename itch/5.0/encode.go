@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/quagmt/udecimal"
+)
+
+// maxTimestamp is the largest number of nanoseconds since midnight that fits in the 6 byte timestamp field
+const maxTimestamp = 1<<48 - 1
+
+// appendHeader appends the fields common to every message
+func appendHeader(dst []byte, messageType uint8, stockLocate, trackingNumber uint16, timestamp time.Duration) []byte {
+	dst = append(dst, messageType)
+	dst = binary.BigEndian.AppendUint16(dst, stockLocate)
+	dst = binary.BigEndian.AppendUint16(dst, trackingNumber)
+
+	ns := uint64(timestamp)
+	return append(dst, byte(ns>>40), byte(ns>>32), byte(ns>>24), byte(ns>>16), byte(ns>>8), byte(ns))
+}
+
+// appendAlpha appends s left justified and padded with spaces to width. Longer strings are truncated, see validAlpha
+func appendAlpha(dst []byte, s string, width int) []byte {
+	if len(s) > width {
+		s = s[:width]
+	}
+
+	dst = append(dst, s...)
+	for i := len(s); i < width; i++ {
+		dst = append(dst, ' ')
+	}
+
+	return dst
+}
+
+func appendBool(dst []byte, b bool) []byte {
+	if b {
+		return append(dst, 'Y')
+	}
+
+	return append(dst, 'N')
+}
+
+// validAlpha reports whether s is printable ASCII that fits in width
+func validAlpha(s string, width int) bool {
+	if len(s) > width {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < ' ' || s[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validator records the first invalid field found when validating a message for encoding. Values are
+// only boxed into the error when a field is invalid so that validation does not allocate
+type validator struct {
+	messageType uint8
+	err         error
+}
+
+func (v *validator) fail(field string, value any) {
+	if v.err == nil {
+		v.err = NewInvalidField(v.messageType, field, value)
+	}
+}
+
+func (v *validator) timestamp(field string, t time.Duration) {
+	if t < 0 || t > maxTimestamp {
+		v.fail(field, t)
+	}
+}
+
+func (v *validator) alpha(field string, s string, width int) {
+	if !validAlpha(s, width) {
+		v.fail(field, s)
+	}
+}
+
+// char checks a single character field is one of the allowed characters
+func (v *validator) char(field string, s string, allowed string) {
+	if len(s) != 1 || !strings.Contains(allowed, s) {
+		v.fail(field, s)
+	}
+}
+
+func (v *validator) price(field string, price udecimal.Decimal, precision uint8) {
+	if !validPrice(price, precision) {
+		v.fail(field, price)
+	}
+}
+
+func (v *validator) enum(field string, valid bool, value uint8) {
+	if !valid {
+		v.fail(field, string(rune(value)))
+	}
+}
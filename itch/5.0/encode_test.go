@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quagmt/udecimal"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	for _, m := range allMessages() {
+		marshaler, ok := m.(encoding.BinaryMarshaler)
+		if !ok {
+			t.Fatalf("%T does not implement MarshalBinary", m)
+		}
+
+		got, err := marshaler.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(%c) error = %v", m.Type(), err)
+		}
+
+		if !bytes.Equal(got, m.Bytes()) {
+			t.Errorf("MarshalBinary(%c) = %v, want %v", m.Type(), got, m.Bytes())
+		}
+	}
+}
+
+func TestAppendBinary_ZeroAllocations(t *testing.T) {
+	dst := make([]byte, 0, 64)
+
+	for _, m := range allMessages() {
+		appender, ok := m.(binaryAppender)
+		if !ok {
+			t.Fatalf("%T does not implement AppendBinary", m)
+		}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = appender.AppendBinary(dst)
+		})
+		if allocs != 0 {
+			t.Errorf("AppendBinary(%c) allocations = %v, want 0", m.Type(), allocs)
+		}
+	}
+}
+
+func TestAppendBinary_Invalid(t *testing.T) {
+	tests := []struct {
+		msg   ItchMessage
+		field string
+	}{
+		{SystemEvent{EventCode: 'Z'}, "EventCode"},
+		{SystemEvent{EventCode: EVENT_START_HOURS, Timestamp: -time.Second}, "Timestamp"},
+		{SystemEvent{EventCode: EVENT_START_HOURS, Timestamp: 100 * time.Hour}, "Timestamp"},
+		{OrderAdd{Stock: "TOOLONGSYMBOL", OrderIndicator: ORDER_INDICATOR_BUY}, "Stock"},
+		{OrderAdd{Stock: "AAPL", OrderIndicator: 'X'}, "OrderIndicator"},
+		{OrderAdd{Stock: "AAPL", OrderIndicator: ORDER_INDICATOR_BUY, Price: udecimal.MustParse("200000.0001")}, "Price"},
+		{OrderAdd{Stock: "AAPL", OrderIndicator: ORDER_INDICATOR_BUY, Price: udecimal.MustParse("1.00001")}, "Price"},
+		{OrderAddAttributed{Stock: "AAPL", OrderIndicator: ORDER_INDICATOR_BUY, Attribution: "GOLDMAN"}, "Attribution"},
+		{OrderReplace{Price: udecimal.MustParse("-1")}, "Price"},
+		{MwcbLevel{LevelOne: udecimal.MustParse("1.000000001")}, "LevelOne"},
+		{MwcbStatus{BreachedLevel: '4'}, "BreachedLevel"},
+		{IpoQuotation{Stock: "AAPL", Qualifier: QUALIFIER_ANTICIPATED, ReleaseTime: 1500 * time.Millisecond}, "ReleaseTime"},
+		{StockDirectory{Stock: "AAPL", MarketCategory: MKTCTG_NYSE, FinancialStatusIndicator: FSI_NORMAL, IssueClassification: IC_COMMON_STOCK, Authenticity: AUTHENTICITY_LIVE}, "ShortSaleThresholdIndicator"},
+		{Noii{Stock: "AAPL", ImbalanceDirection: IMBALANCE_BUY, CrossType: CROSS_TYPE_NASDAQ_OPEN, VariationIndicator: 'Z'}, "VariationIndicator"},
+		{StockTradingAction{Stock: "AAPL", TradingState: STATE_HALTED, Reason: "\x00"}, "Reason"},
+	}
+
+	for _, tt := range tests {
+		dst := []byte{1, 2, 3}
+
+		got, err := tt.msg.(binaryAppender).AppendBinary(dst)
+
+		var invalid ErrInvalidField
+		if !errors.As(err, &invalid) {
+			t.Errorf("AppendBinary(%c) error = %v, want ErrInvalidField", tt.msg.Type(), err)
+			continue
+		}
+		if invalid.Field != tt.field || invalid.MessageType != tt.msg.Type() {
+			t.Errorf("AppendBinary(%c) invalid field = %c %v, want %c %v", tt.msg.Type(), invalid.MessageType, invalid.Field, tt.msg.Type(), tt.field)
+		}
+		if !bytes.Equal(got, dst) {
+			t.Errorf("AppendBinary(%c) = %v, want dst unchanged", tt.msg.Type(), got)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package itch
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 type ErrInvalidPacketSize struct {
 	err error
@@ -29,3 +32,134 @@ func NewInvalidPacketType(t byte) ErrInvalidPacketType {
 		err: fmt.Errorf("invalid packet type=%v", t),
 	}
 }
+
+// ErrUnsupportedMessage is returned when an ItchMessage is not one of the message types defined by this package,
+// or is a nil pointer to one
+type ErrUnsupportedMessage struct {
+	err error
+}
+
+func (e ErrUnsupportedMessage) Error() string {
+	return e.err.Error()
+}
+
+func NewUnsupportedMessage(msg ItchMessage) ErrUnsupportedMessage {
+	return ErrUnsupportedMessage{
+		err: fmt.Errorf("unsupported message %T", msg),
+	}
+}
+
+// ErrTruncatedMessage is returned when the input ends part way through a message. It wraps io.ErrUnexpectedEOF
+type ErrTruncatedMessage struct {
+	err error
+}
+
+func (e ErrTruncatedMessage) Error() string {
+	return e.err.Error()
+}
+
+func (e ErrTruncatedMessage) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+func NewTruncatedMessage(w int) ErrTruncatedMessage {
+	return ErrTruncatedMessage{
+		err: fmt.Errorf("truncated message: expected %d bytes but reached end of input", w),
+	}
+}
+
+// ErrSkippedData is returned when data without a length field prefix contains an unknown message type and
+// is skipped until it looks like a message again
+type ErrSkippedData struct {
+	err error
+}
+
+func (e ErrSkippedData) Error() string {
+	return e.err.Error()
+}
+
+func NewSkippedData(n int) ErrSkippedData {
+	return ErrSkippedData{
+		err: fmt.Errorf("unknown packet type, skipped %d bytes", n),
+	}
+}
+
+// ErrInvalidField is returned when encoding a message with a field value that cannot be represented in ITCH
+type ErrInvalidField struct {
+	MessageType uint8
+	Field       string
+	err         error
+}
+
+func (e ErrInvalidField) Error() string {
+	return e.err.Error()
+}
+
+func NewInvalidField(messageType uint8, field string, value any) ErrInvalidField {
+	return ErrInvalidField{
+		MessageType: messageType,
+		Field:       field,
+		err:         fmt.Errorf("invalid field %s=%v for message type=%c", field, value, messageType),
+	}
+}
+
+// DecodeError is a message that failed to parse and where it was found in the input
+type DecodeError struct {
+	// Offset of the message in bytes from the start of the input, including any length field prefix
+	Offset int64
+	// Index of the message from the start of the input, counting messages not selected by MessageTypes
+	Index       int
+	MessageType uint8
+	Err         error
+}
+
+func (e DecodeError) Error() string {
+	return fmt.Sprintf("message %d at offset %d type=%c: %v", e.Index, e.Offset, e.MessageType, e.Err)
+}
+
+func (e DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func NewDecodeError(offset int64, index int, messageType uint8, err error) DecodeError {
+	return DecodeError{
+		Offset:      offset,
+		Index:       index,
+		MessageType: messageType,
+		Err:         err,
+	}
+}
+
+// ErrInvalidIndex is returned when an index file is corrupt or does not match the ITCH file it is used with
+type ErrInvalidIndex struct {
+	err error
+}
+
+func (e ErrInvalidIndex) Error() string {
+	return e.err.Error()
+}
+
+func NewInvalidIndex(reason string) ErrInvalidIndex {
+	return ErrInvalidIndex{
+		err: fmt.Errorf("invalid index: %s", reason),
+	}
+}
+
+// ErrInvalidPhaseTransition is returned by PhaseTracker when System Event messages arrive out of order
+type ErrInvalidPhaseTransition struct {
+	From EventCode
+	To   EventCode
+	err  error
+}
+
+func (e ErrInvalidPhaseTransition) Error() string {
+	return e.err.Error()
+}
+
+func NewInvalidPhaseTransition(from, to EventCode) ErrInvalidPhaseTransition {
+	return ErrInvalidPhaseTransition{
+		From: from,
+		To:   to,
+		err:  fmt.Errorf("invalid phase transition from %q to %q", from, to),
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"slices"
+	"sync"
+)
+
+// FeedState holds the securities directory and market participant positions of a single ITCH feed.
+// It is built from Stock Directory and Market Participant Position messages and is safe for
+// concurrent use, so it can be read while a Decoder is still populating it.
+//
+// FeedState implements Handler so it can be passed to DecodeTo or used with Dispatch.
+type FeedState struct {
+	NopHandler
+
+	mu           sync.RWMutex
+	stocks       map[string]uint16
+	directory    map[uint16]StockDirectory
+	participants map[string][]ParticipantPosition
+}
+
+// NewFeedState creates an empty FeedState
+func NewFeedState() *FeedState {
+	return &FeedState{
+		stocks:       make(map[string]uint16),
+		directory:    make(map[uint16]StockDirectory),
+		participants: make(map[string][]ParticipantPosition),
+	}
+}
+
+// Apply updates the state with a single ITCH message. Messages other than Stock Directory and Market
+// Participant Position are ignored
+func (s *FeedState) Apply(msg ItchMessage) {
+	switch m := msg.(type) {
+	case StockDirectory:
+		s.OnStockDirectory(m)
+	case ParticipantPosition:
+		s.OnParticipantPosition(m)
+	}
+}
+
+func (s *FeedState) OnStockDirectory(m StockDirectory) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stocks[m.Stock] = m.StockLocate
+	s.directory[m.StockLocate] = m
+}
+
+func (s *FeedState) OnParticipantPosition(m ParticipantPosition) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.participants[m.Mpid] = append(s.participants[m.Mpid], m)
+}
+
+// Locate returns the stock locate code assigned to a stock symbol
+func (s *FeedState) Locate(stock string) (uint16, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	locate, ok := s.stocks[stock]
+	return locate, ok
+}
+
+// StockDirectory returns the Stock Directory message for a stock locate code
+func (s *FeedState) StockDirectory(stockLocate uint16) (StockDirectory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sd, ok := s.directory[stockLocate]
+	return sd, ok
+}
+
+// Participants returns every position message received for a market participant, in the order received
+func (s *FeedState) Participants(mpid string) []ParticipantPosition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.participants[mpid])
+}
+
+// applyData updates the state from a raw message if it is one the state tracks. Messages that fail
+// to parse are ignored here and reported by the caller's own parsing
+func (s *FeedState) applyData(data []byte, symbols symbolTable) {
+	if len(data) == 0 {
+		return
+	}
+
+	switch data[0] {
+	case MESSAGE_STOCK_DIRECTORY:
+		var m StockDirectory
+		if err := m.decode(data, symbols); err == nil {
+			s.OnStockDirectory(m)
+		}
+	case MESSAGE_PARTICIPANT_POSITION:
+		var m ParticipantPosition
+		if err := m.decode(data, symbols); err == nil {
+			s.OnParticipantPosition(m)
+		}
+	}
+}
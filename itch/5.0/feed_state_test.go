@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFeedState_Decoder(t *testing.T) {
+	directory := StockDirectory{
+		Timestamp:                   3 * time.Hour,
+		Stock:                       "NMRK",
+		StockLocate:                 5579,
+		TrackingNumber:              2,
+		RoundLotSize:                100,
+		IssueSubType:                ICS_NOT_APPLICABLE,
+		IssueClassification:         IC_COMMON_STOCK,
+		Authenticity:                AUTHENTICITY_LIVE,
+		ShortSaleThresholdIndicator: "N",
+		IpoFlag:                     " ",
+		LuldReferencePriceTier:      "2",
+		EtpFlag:                     "N",
+		MarketCategory:              MKTCTG_NASDAQ_GLOBAL_SELECT,
+		FinancialStatusIndicator:    FSI_NORMAL,
+	}
+	position := ParticipantPosition{
+		StockLocate:    5579,
+		TrackingNumber: 3,
+		Timestamp:      3 * time.Hour,
+		Mpid:           "COWN",
+		Stock:          "NMRK",
+		PrimaryMM:      true,
+		Mode:           MMMODE_NORMAL,
+		State:          MMSTATE_ACTIVE,
+	}
+
+	messages := append([]ItchMessage{directory, position}, testMessages()...)
+	config := Configuration{MessageTypes: []byte{MESSAGE_STOCK_DIRECTORY, MESSAGE_ORDER_ADD}}
+
+	d := NewDecoder(bytes.NewReader(encodeMessages(messages, false)), config)
+
+	got := []ItchMessage{}
+	for {
+		m, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decoder.Next() error = %v", err)
+		}
+		got = append(got, m)
+	}
+
+	// Updating the state must not change the messages returned to the caller
+	if want := []ItchMessage{directory, testMessages()[1]}; !cmp.Equal(got, want) {
+		t.Errorf("%v", cmp.Diff(want, got))
+	}
+
+	state := d.State()
+
+	locate, ok := state.Locate("NMRK")
+	if !ok || locate != 5579 {
+		t.Errorf("Locate() = %v, %v, want 5579", locate, ok)
+	}
+
+	sd, ok := state.StockDirectory(5579)
+	if !ok || !cmp.Equal(sd, directory) {
+		t.Errorf("StockDirectory() = %v, %v, want %v", sd, ok, directory)
+	}
+
+	// Participant positions are tracked even though they were not selected by MessageTypes
+	if got, want := state.Participants("COWN"), []ParticipantPosition{position}; !cmp.Equal(got, want) {
+		t.Errorf("%v", cmp.Diff(want, got))
+	}
+}
+
+func TestFeedState_Separate(t *testing.T) {
+	first := NewFeedState()
+	second := NewFeedState()
+
+	_, err := ParseMany(encodeMessages([]ItchMessage{StockDirectory{Stock: "AAPL", StockLocate: 13, ShortSaleThresholdIndicator: "N", IpoFlag: "N", LuldReferencePriceTier: "1", EtpFlag: "N"}}, false), Configuration{FeedState: first})
+	if err != nil {
+		t.Fatalf("ParseMany() error = %v", err)
+	}
+
+	if _, ok := first.Locate("AAPL"); !ok {
+		t.Errorf("first.Locate() not found")
+	}
+	if _, ok := second.Locate("AAPL"); ok {
+		t.Errorf("second.Locate() found a stock from another feed")
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/quagmt/udecimal"
+)
+
+// FieldKind is how a message field is represented by exporters that write messages as tables, such as
+// CSVExporter and the columnar package
+type FieldKind uint8
+
+const (
+	FIELD_KIND_UNKNOWN FieldKind = iota
+	// FIELD_KIND_TIMESTAMP is a time.Duration of nanoseconds since midnight
+	FIELD_KIND_TIMESTAMP
+	// FIELD_KIND_PRICE is a udecimal.Decimal
+	FIELD_KIND_PRICE
+	// FIELD_KIND_CODE is a uint8 enum or other single character code. ITCH has no single byte integers, so
+	// every uint8 field is a code. Use CodeString to format it
+	FIELD_KIND_CODE
+	FIELD_KIND_STRING
+	FIELD_KIND_BOOL
+	// FIELD_KIND_INTEGER is a uint16, uint32 or uint64
+	FIELD_KIND_INTEGER
+)
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	decimalType  = reflect.TypeFor[udecimal.Decimal]()
+)
+
+// MessageField describes one field of a message type
+type MessageField struct {
+	// Name is the field's JSON name, or its Go name if it has no JSON tag
+	Name string
+	// Index is the position of the field in the message struct, for reflect.Value.Field
+	Index int
+	Type  reflect.Type
+	Kind  FieldKind
+}
+
+// MessageValue returns the struct value of a message, dereferencing pointers to messages. It returns an
+// ErrUnsupportedMessage if msg is not a struct or a non-nil pointer to one, or if the struct value does not
+// implement ItchMessage itself
+func MessageValue(msg ItchMessage) (reflect.Value, error) {
+	if msg == nil {
+		return reflect.Value{}, NewInvalidPacketType(0)
+	}
+
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, NewUnsupportedMessage(msg)
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, NewUnsupportedMessage(msg)
+	}
+	if _, ok := v.Interface().(ItchMessage); !ok {
+		return reflect.Value{}, NewUnsupportedMessage(msg)
+	}
+
+	return v, nil
+}
+
+// MessageFields returns the fields of a message struct type in order, such as the type of a value returned
+// by MessageValue
+func MessageFields(t reflect.Type) []MessageField {
+	fields := make([]MessageField, 0, t.NumField())
+
+	for i := range t.NumField() {
+		f := t.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		fields = append(fields, MessageField{Name: name, Index: i, Type: f.Type, Kind: fieldKind(f.Type)})
+	}
+
+	return fields
+}
+
+func fieldKind(t reflect.Type) FieldKind {
+	switch {
+	case t == durationType:
+		return FIELD_KIND_TIMESTAMP
+	case t == decimalType:
+		return FIELD_KIND_PRICE
+	}
+
+	switch t.Kind() {
+	case reflect.Uint8:
+		return FIELD_KIND_CODE
+	case reflect.String:
+		return FIELD_KIND_STRING
+	case reflect.Bool:
+		return FIELD_KIND_BOOL
+	case reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FIELD_KIND_INTEGER
+	}
+
+	return FIELD_KIND_UNKNOWN
+}
+
+// CodeString returns a single character code as a string. A zero code is unset and returned as ""
+func CodeString(code uint8) string {
+	if code == 0 {
+		return ""
+	}
+
+	return string(rune(code))
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMessageFields(t *testing.T) {
+	type field struct {
+		Name  string
+		Index int
+		Kind  FieldKind
+	}
+
+	want := []field{
+		{"stock", 0, FIELD_KIND_STRING},
+		{"timestamp", 1, FIELD_KIND_TIMESTAMP},
+		{"reference", 2, FIELD_KIND_INTEGER},
+		{"shares", 3, FIELD_KIND_INTEGER},
+		{"price", 4, FIELD_KIND_PRICE},
+		{"stockLocate", 5, FIELD_KIND_INTEGER},
+		{"trackingNumber", 6, FIELD_KIND_INTEGER},
+		{"orderIndicator", 7, FIELD_KIND_CODE},
+	}
+
+	got := []field{}
+	for _, f := range MessageFields(reflect.TypeFor[OrderAdd]()) {
+		got = append(got, field{f.Name, f.Index, f.Kind})
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("%v", cmp.Diff(want, got))
+	}
+
+	// Every field of every message type can be exported
+	for _, m := range allMessages() {
+		for _, f := range MessageFields(reflect.TypeOf(m)) {
+			if f.Kind == FIELD_KIND_UNKNOWN {
+				t.Errorf("%T field %s has an unknown kind", m, f.Name)
+			}
+		}
+	}
+}
+
+func TestMessageValue(t *testing.T) {
+	order := testMessages()[1].(OrderAdd)
+
+	for _, m := range []ItchMessage{order, &order} {
+		v, err := MessageValue(m)
+		if err != nil {
+			t.Fatalf("MessageValue(%T) error = %v", m, err)
+		}
+		if got := v.Interface(); !cmp.Equal(got, order) {
+			t.Errorf("MessageValue(%T) = %v, want %v", m, got, order)
+		}
+	}
+
+	var nilOrder *OrderAdd
+	if _, err := MessageValue(nilOrder); !errors.As(err, &ErrUnsupportedMessage{}) {
+		t.Errorf("MessageValue((*OrderAdd)(nil)) error = %v, want ErrUnsupportedMessage", err)
+	}
+	if _, err := MessageValue(nil); !errors.As(err, &ErrInvalidPacketType{}) {
+		t.Errorf("MessageValue(nil) error = %v, want ErrInvalidPacketType", err)
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	tests := []struct {
+		code uint8
+		want string
+	}{
+		{'B', "B"},
+		{' ', " "},
+		{0, ""},
+	}
+	for _, tt := range tests {
+		if got := CodeString(tt.code); got != tt.want {
+			t.Errorf("CodeString(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"slices"
+	"time"
+)
+
+// messageFilter selects the messages wanted by a Configuration using only their header, so that messages
+// which are not wanted are never decoded
+type messageFilter struct {
+	types []byte
+
+	// symbols are the wanted stocks and locates their stock locate codes, learnt from Stock Directory messages
+	symbols map[string]struct{}
+	locates map[uint16]struct{}
+
+	start     time.Duration
+	end       time.Duration
+	predicate func(Header) bool
+}
+
+func newMessageFilter(config Configuration, state *FeedState) messageFilter {
+	f := messageFilter{
+		types:     config.MessageTypes,
+		start:     config.StartTime,
+		end:       config.EndTime,
+		predicate: config.Filter,
+	}
+
+	if len(config.Symbols) > 0 {
+		f.symbols = make(map[string]struct{}, len(config.Symbols))
+		f.locates = make(map[uint16]struct{}, len(config.Symbols))
+
+		for _, symbol := range config.Symbols {
+			f.symbols[symbol] = struct{}{}
+
+			// A FeedState passed in the Configuration may already know the stock locate
+			if locate, ok := state.Locate(symbol); ok {
+				f.locates[locate] = struct{}{}
+			}
+		}
+	}
+
+	return f
+}
+
+// empty reports whether every message is selected
+func (f *messageFilter) empty() bool {
+	return len(f.types) == 0 && f.symbols == nil && f.start == 0 && f.end == 0 && f.predicate == nil
+}
+
+// selects reports whether the raw message data is wanted. It must see every message, including those it
+// does not select, so that it learns the stock locate of each wanted symbol
+func (f *messageFilter) selects(data []byte, symbols symbolTable) bool {
+	if f.empty() {
+		return true
+	}
+
+	// Too short to filter, so let parsing report the error
+	if len(data) < headerSize {
+		return true
+	}
+
+	if f.symbols != nil && data[0] == MESSAGE_STOCK_DIRECTORY && len(data) == stockDirectorySize {
+		if _, ok := f.symbols[symbols.get(data[11:19])]; ok {
+			f.locates[parseHeader(data).StockLocate] = struct{}{}
+		}
+	}
+
+	if len(f.types) != 0 && !slices.Contains(f.types, data[0]) {
+		return false
+	}
+
+	header := parseHeader(data)
+
+	// Stock Locate 0 is used by messages that apply to the whole market, such as System Event
+	if f.symbols != nil && header.StockLocate != 0 {
+		if _, ok := f.locates[header.StockLocate]; !ok {
+			return false
+		}
+	}
+
+	if header.Timestamp < f.start || (f.end > 0 && header.Timestamp >= f.end) {
+		return false
+	}
+
+	if f.predicate != nil && !f.predicate(header) {
+		return false
+	}
+
+	return true
+}
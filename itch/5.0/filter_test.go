@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func filterMessages() []ItchMessage {
+	return []ItchMessage{
+		SystemEvent{Timestamp: 3 * time.Hour, EventCode: EVENT_START_MESSAGES},
+		StockDirectory{Timestamp: 3 * time.Hour, Stock: "AAPL", StockLocate: 1, RoundLotSize: 100, ShortSaleThresholdIndicator: "N", IpoFlag: "N", LuldReferencePriceTier: "1", EtpFlag: "N"},
+		StockDirectory{Timestamp: 3 * time.Hour, Stock: "MSFT", StockLocate: 2, RoundLotSize: 100, ShortSaleThresholdIndicator: "N", IpoFlag: "N", LuldReferencePriceTier: "1", EtpFlag: "N"},
+		StockDirectory{Timestamp: 3 * time.Hour, Stock: "ERIC", StockLocate: 3, RoundLotSize: 100, ShortSaleThresholdIndicator: "N", IpoFlag: "N", LuldReferencePriceTier: "1", EtpFlag: "N"},
+		OrderAdd{StockLocate: 1, Timestamp: 9*time.Hour + 30*time.Minute, Reference: 1, OrderIndicator: ORDER_INDICATOR_BUY, Shares: 100, Stock: "AAPL"},
+		OrderAdd{StockLocate: 3, Timestamp: 9*time.Hour + 31*time.Minute, Reference: 2, OrderIndicator: ORDER_INDICATOR_SELL, Shares: 100, Stock: "ERIC"},
+		OrderExecuted{StockLocate: 1, Timestamp: 9*time.Hour + 45*time.Minute, Reference: 1, Shares: 100, MatchNumber: 1},
+		OrderExecuted{StockLocate: 3, Timestamp: 9*time.Hour + 50*time.Minute, Reference: 2, Shares: 100, MatchNumber: 2},
+		OrderAdd{StockLocate: 2, Timestamp: 10 * time.Hour, Reference: 3, OrderIndicator: ORDER_INDICATOR_BUY, Shares: 100, Stock: "MSFT"},
+		SystemEvent{Timestamp: 20 * time.Hour, EventCode: EVENT_END_MESSAGES},
+	}
+}
+
+func TestParseMany_Filter(t *testing.T) {
+	messages := filterMessages()
+	data := encodeMessages(messages, true)
+
+	tests := []struct {
+		name   string
+		config Configuration
+		want   []int
+	}{
+		{"no filter", Configuration{}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{"symbols", Configuration{Symbols: []string{"AAPL", "MSFT"}}, []int{0, 1, 2, 4, 6, 8, 9}},
+		{"unknown symbol", Configuration{Symbols: []string{"TSLA"}}, []int{0, 9}},
+		{"symbols and types", Configuration{Symbols: []string{"AAPL"}, MessageTypes: []byte{MESSAGE_ORDER_EXECUTED}}, []int{6}},
+		{"start time", Configuration{StartTime: 9*time.Hour + 45*time.Minute}, []int{6, 7, 8, 9}},
+		{"time window", Configuration{StartTime: 9*time.Hour + 30*time.Minute, EndTime: 10 * time.Hour}, []int{4, 5, 6, 7}},
+		{"symbol and time window", Configuration{Symbols: []string{"ERIC"}, StartTime: 9 * time.Hour, EndTime: 10 * time.Hour}, []int{5, 7}},
+		{"predicate", Configuration{Filter: func(h Header) bool { return h.Type == MESSAGE_ORDER_ADD && h.StockLocate != 2 }}, []int{4, 5}},
+		{"max messages", Configuration{Symbols: []string{"AAPL"}, MaxMessages: 3}, []int{0, 1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []ItchMessage{}
+			for _, i := range tt.want {
+				want = append(want, messages[i])
+			}
+
+			tt.config.LengthFieldPrefixed = true
+
+			got, err := ParseMany(data, tt.config)
+			if err != nil {
+				t.Fatalf("ParseMany() error = %v", err)
+			}
+			if !cmp.Equal(got, want) {
+				t.Errorf("%v", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestParseMany_FilterKnownLocates(t *testing.T) {
+	messages := filterMessages()
+
+	// The Stock Directory messages were read into the FeedState earlier, for example from another part of the file
+	state := NewFeedState()
+	for _, m := range messages[1:4] {
+		state.Apply(m)
+	}
+
+	data := encodeMessages(messages[4:], false)
+
+	got, err := ParseMany(data, Configuration{Symbols: []string{"ERIC"}, FeedState: state})
+	if err != nil {
+		t.Fatalf("ParseMany() error = %v", err)
+	}
+
+	want := []ItchMessage{messages[5], messages[7], messages[9]}
+	if !cmp.Equal(got, want) {
+		t.Errorf("%v", cmp.Diff(want, got))
+	}
+}
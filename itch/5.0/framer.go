@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import "errors"
+
+// Framer decodes ITCH messages pushed to it in chunks of any size, such as the payloads of TCP or UDP reads,
+// and passes each complete message to a Handler. Partial messages are buffered until the rest arrives in a
+// later chunk, so a chunk does not need to end on a message boundary.
+//
+// Messages that are complete within a chunk are decoded directly from it without copying. Messages are
+// filtered and the FeedState is populated in the same way as by a Decoder. Gzip compressed input is not
+// supported. A Framer is not safe for concurrent use.
+type Framer struct {
+	config  Configuration
+	handler Handler
+	state   *FeedState
+	symbols symbolTable
+	filter  messageFilter
+
+	// pending holds the start of a message, including any length field prefix, whose end has not arrived yet
+	pending []byte
+
+	// consumed is the number of bytes framed and index the number of messages framed, including those not
+	// selected by the filters. count is the number of messages passed to the handler
+	consumed int64
+	index    int
+	count    int
+
+	// skipped counts the bytes of unknown data, without a length field prefix, that have been skipped since
+	// skipOffset
+	skipped    int
+	skipOffset int64
+	skipType   uint8
+
+	// errs collects the errors of the current call to Feed. err is the error that stopped the Framer
+	errs error
+	err  error
+}
+
+// NewFramer creates a Framer passing messages to handler. Configuration.LengthFieldPrefixed chooses the
+// framing. ReadBufferSize, DecompressAhead, Workers and Unordered do not apply
+func NewFramer(config Configuration, handler Handler) *Framer {
+	state := config.FeedState
+	if state == nil {
+		state = NewFeedState()
+	}
+
+	return &Framer{
+		config:  config,
+		handler: handler,
+		state:   state,
+		symbols: newSymbolTable(),
+		filter:  newMessageFilter(config, state),
+	}
+}
+
+// State returns the FeedState populated by the Framer. It is Configuration.FeedState if that was set
+func (f *Framer) State() *FeedState {
+	return f.state
+}
+
+// Buffered returns the number of bytes of an incomplete message waiting for the rest of its data
+func (f *Framer) Buffered() int {
+	return len(f.pending)
+}
+
+// Write implements io.Writer by calling Feed. It always consumes all of p. Since a non-nil error stops io.Copy,
+// use ERROR_POLICY_SKIP or call Feed directly to keep going after a message fails to parse
+func (f *Framer) Write(p []byte) (int, error) {
+	return len(p), f.Feed(p)
+}
+
+// Feed frames and decodes every complete message in chunk, along with any incomplete message left by earlier
+// calls, and buffers the start of a message that continues in the next chunk. Errors parsing a message are
+// handled according to Configuration.ErrorPolicy, by default they are joined together and returned after
+// passing on every message in chunk.
+func (f *Framer) Feed(chunk []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.errs = nil
+
+	if len(f.pending) > 0 {
+		// Complete the pending message from the start of chunk
+		for {
+			size := f.frameSize(f.pending)
+
+			want := size
+			if size < 0 {
+				want = f.prefixSize()
+			}
+
+			n := min(want-len(f.pending), len(chunk))
+			f.pending = append(f.pending, chunk[:n]...)
+			chunk = chunk[n:]
+
+			if len(f.pending) < want {
+				return f.result()
+			}
+			if size >= 0 {
+				break
+			}
+		}
+
+		f.frame(f.pending)
+		f.pending = f.pending[:0]
+	}
+
+	for len(chunk) > 0 && f.err == nil {
+		size := f.frameSize(chunk)
+		if size < 0 || size > len(chunk) {
+			f.pending = append(f.pending, chunk...)
+			break
+		}
+
+		f.frame(chunk[:size])
+		chunk = chunk[size:]
+	}
+
+	return f.result()
+}
+
+// Close reports any skipped data and returns an error wrapping ErrTruncatedMessage if the input ended part
+// way through a message. It does not call the handler
+func (f *Framer) Close() error {
+	if f.err != nil {
+		return f.err
+	}
+	f.errs = nil
+
+	f.reportSkipped()
+
+	if len(f.pending) > 0 {
+		want := f.frameSize(f.pending)
+		if want < 0 {
+			want = f.prefixSize()
+		}
+
+		msgType := uint8(0)
+		if len(f.pending) > f.prefixSize() {
+			msgType = f.pending[f.prefixSize()]
+		}
+
+		f.errs = errors.Join(f.errs, NewDecodeError(f.consumed, f.index, msgType, NewTruncatedMessage(want)))
+		f.pending = f.pending[:0]
+	}
+
+	return f.result()
+}
+
+// prefixSize is the number of bytes needed to know the size of a message
+func (f *Framer) prefixSize() int {
+	if f.config.LengthFieldPrefixed {
+		return 2
+	}
+
+	return 1
+}
+
+// frameSize returns the size of the message at the start of data, including any length field prefix, or -1 if
+// data is too short to know it yet. Without a length field prefix an unknown message type has size 1, so it
+// is skipped a byte at a time
+func (f *Framer) frameSize(data []byte) int {
+	if len(data) < f.prefixSize() {
+		return -1
+	}
+
+	if f.config.LengthFieldPrefixed {
+		return 2 + int(uint16(data[1])|uint16(data[0])<<8)
+	}
+
+	return max(getMessageSize(data[0]), 1)
+}
+
+// frame decodes a single complete message, including any length field prefix, and passes it to the handler
+func (f *Framer) frame(frame []byte) {
+	offset := f.consumed
+	f.consumed += int64(len(frame))
+
+	data := frame
+	if f.config.LengthFieldPrefixed {
+		data = frame[2:]
+
+		// An empty frame holds no message, so skip over its length field
+		if len(data) == 0 {
+			f.report(NewDecodeError(offset, f.index, 0, NewInvalidPacketSize(1, 0)))
+			return
+		}
+	} else if getMessageSize(data[0]) == 0 {
+		if f.skipped == 0 {
+			f.skipOffset = offset
+			f.skipType = data[0]
+		}
+		f.skipped++
+		return
+	}
+
+	f.reportSkipped()
+
+	index := f.index
+	f.index++
+
+	f.state.applyData(data, f.symbols)
+
+	if !f.filter.selects(data, f.symbols) {
+		return
+	}
+
+	if f.config.MaxMessages > 0 && f.count >= f.config.MaxMessages {
+		return
+	}
+	f.count++
+
+	if err := dispatchData(data, f.handler, f.symbols); err != nil {
+		f.report(NewDecodeError(offset, index, data[0], err))
+	}
+}
+
+// reportSkipped reports the unknown data skipped before a known message type was found
+func (f *Framer) reportSkipped() {
+	if f.skipped == 0 {
+		return
+	}
+
+	f.report(NewDecodeError(f.skipOffset, f.index, f.skipType, NewSkippedData(f.skipped)))
+	f.skipped = 0
+}
+
+// report applies Configuration.ErrorPolicy to a DecodeError
+func (f *Framer) report(err DecodeError) {
+	switch f.config.ErrorPolicy {
+	case ERROR_POLICY_SKIP:
+	case ERROR_POLICY_STOP:
+		f.err = err
+	default:
+		f.errs = errors.Join(f.errs, err)
+	}
+}
+
+// result returns the errors of the current call
+func (f *Framer) result() error {
+	if f.err != nil {
+		return f.err
+	}
+
+	return f.errs
+}
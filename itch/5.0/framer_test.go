@@ -0,0 +1,132 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFramer_ChunkSizes(t *testing.T) {
+	for _, prefixed := range []bool{true, false} {
+		data := encodeMessages(testMessages(), prefixed)
+
+		for size := 1; size <= len(data); size++ {
+			h := &recordingHandler{}
+			f := NewFramer(Configuration{LengthFieldPrefixed: prefixed}, h)
+
+			for chunk := range slices.Chunk(data, size) {
+				if err := f.Feed(chunk); err != nil {
+					t.Fatalf("Feed(prefixed=%v, size=%d) error = %v", prefixed, size, err)
+				}
+			}
+
+			if err := f.Close(); err != nil {
+				t.Errorf("Close(prefixed=%v, size=%d) error = %v", prefixed, size, err)
+			}
+
+			if !cmp.Equal(h.messages, testMessages()) {
+				t.Errorf("prefixed=%v, size=%d: %v", prefixed, size, cmp.Diff(testMessages(), h.messages))
+			}
+		}
+	}
+}
+
+func TestFramer_UnknownMessages(t *testing.T) {
+	messages := testMessages()
+
+	raw := encodeMessages(messages[:2], false)
+	garbageOffset := len(raw)
+	raw = append(raw, 0, 0xff, '!', 0x7f, 0)
+	raw = append(raw, encodeMessages(messages[2:], false)...)
+
+	h := &recordingHandler{}
+	f := NewFramer(Configuration{}, h)
+
+	errs := error(nil)
+	for chunk := range slices.Chunk(raw, 3) {
+		errs = errors.Join(errs, f.Feed(chunk))
+	}
+	errs = errors.Join(errs, f.Close())
+
+	if !cmp.Equal(h.messages, messages) {
+		t.Errorf("%v", cmp.Diff(messages, h.messages))
+	}
+
+	var decodeErr DecodeError
+	var skipped ErrSkippedData
+	if !errors.As(errs, &decodeErr) || decodeErr.Offset != int64(garbageOffset) || !errors.As(errs, &skipped) {
+		t.Fatalf("Feed() error = %v, want ErrSkippedData at offset %d", errs, garbageOffset)
+	}
+	if skipped.Error() != "unknown packet type, skipped 5 bytes" {
+		t.Errorf("Feed() error = %v", skipped)
+	}
+}
+
+func TestFramer_Truncated(t *testing.T) {
+	data := encodeMessages(testMessages(), true)
+
+	h := &recordingHandler{}
+	f := NewFramer(Configuration{LengthFieldPrefixed: true}, h)
+
+	if err := f.Feed(data[:len(data)-3]); err != nil {
+		t.Fatalf("Feed() error = %v", err)
+	}
+
+	if f.Buffered() == 0 {
+		t.Errorf("Buffered() = 0, want the partial message")
+	}
+
+	if err := f.Close(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Close() error = %v, want truncation error", err)
+	}
+
+	if want := testMessages()[:4]; !cmp.Equal(h.messages, want) {
+		t.Errorf("%v", cmp.Diff(want, h.messages))
+	}
+}
+
+func TestFramer_ErrorPolicy(t *testing.T) {
+	messages := testMessages()
+
+	data := encodeMessages(messages[:2], true)
+	data = append(data, 0, 5, 'Z', 1, 2, 3, 4)
+	data = append(data, encodeMessages(messages[2:], true)...)
+
+	tests := []struct {
+		policy  ErrorPolicy
+		want    []ItchMessage
+		wantErr bool
+	}{
+		{ERROR_POLICY_COLLECT, messages, true},
+		{ERROR_POLICY_SKIP, messages, false},
+		{ERROR_POLICY_STOP, messages[:2], true},
+	}
+	for _, tt := range tests {
+		h := &recordingHandler{}
+		f := NewFramer(Configuration{LengthFieldPrefixed: true, ErrorPolicy: tt.policy}, h)
+
+		// io.Copy writes the data in one chunk
+		_, err := io.Copy(f, bytes.NewReader(data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("policy %d: io.Copy() error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+
+		if !cmp.Equal(h.messages, tt.want) {
+			t.Errorf("policy %d: %v", tt.policy, cmp.Diff(tt.want, h.messages))
+		}
+
+		if tt.policy == ERROR_POLICY_STOP {
+			if err := f.Feed(data); err == nil {
+				t.Errorf("Feed() after stopping expected error")
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"sync"
+)
+
+const (
+	readAheadBlockSize = 1 << 20
+	readAheadBlocks    = 4
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isGzip reports whether the reader starts with the gzip magic bytes. No valid ITCH message, with or
+// without a length prefix, starts with them
+func isGzip(reader *bufio.Reader) bool {
+	magic, _ := reader.Peek(len(gzipMagic))
+	return bytes.Equal(magic, gzipMagic)
+}
+
+// newGzipReader decompresses reader. If ahead is set decompression runs on its own goroutine, ahead
+// of the caller's reads, and the returned io.Closer must be called to stop it
+func newGzipReader(reader *bufio.Reader, ahead bool) (io.Reader, io.Closer, error) {
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !ahead {
+		return gz, gz, nil
+	}
+
+	ra := newReadAhead(gz)
+	return ra, ra, nil
+}
+
+// readAhead reads blocks from a reader on a separate goroutine so that reading, such as decompressing,
+// runs at the same time as the caller parses the previous blocks
+type readAhead struct {
+	blocks chan []byte
+	free   chan []byte
+	done   chan struct{}
+	once   sync.Once
+
+	block   []byte
+	current []byte
+
+	// err is the error that stopped the reading goroutine. It is set before blocks is closed
+	err error
+}
+
+func newReadAhead(reader io.Reader) *readAhead {
+	ra := &readAhead{
+		blocks: make(chan []byte, readAheadBlocks),
+		free:   make(chan []byte, readAheadBlocks+1),
+		done:   make(chan struct{}),
+	}
+
+	for range readAheadBlocks + 1 {
+		ra.free <- make([]byte, readAheadBlockSize)
+	}
+
+	go ra.fill(reader)
+
+	return ra
+}
+
+func (ra *readAhead) fill(reader io.Reader) {
+	defer close(ra.blocks)
+
+	for {
+		var block []byte
+		select {
+		case block = <-ra.free:
+		case <-ra.done:
+			ra.err = io.ErrClosedPipe
+			return
+		}
+
+		n, err := io.ReadFull(reader, block)
+		if n > 0 {
+			select {
+			case ra.blocks <- block[:n]:
+			case <-ra.done:
+				ra.err = io.ErrClosedPipe
+				return
+			}
+		}
+
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		if err != nil {
+			ra.err = err
+			return
+		}
+	}
+}
+
+func (ra *readAhead) Read(p []byte) (int, error) {
+	for len(ra.current) == 0 {
+		if ra.block != nil {
+			ra.free <- ra.block[:cap(ra.block)]
+			ra.block = nil
+		}
+
+		block, ok := <-ra.blocks
+		if !ok {
+			return 0, ra.err
+		}
+
+		ra.block = block
+		ra.current = block
+	}
+
+	n := copy(p, ra.current)
+	ra.current = ra.current[n:]
+
+	return n, nil
+}
+
+// Close stops the reading goroutine. It does not close the underlying reader
+func (ra *readAhead) Close() error {
+	ra.once.Do(func() {
+		close(ra.done)
+	})
+
+	return nil
+}
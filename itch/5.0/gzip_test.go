@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func gzipMessages(t testing.TB, messages []ItchMessage) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(encodeMessages(messages, true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestParseFile_Gzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "01302020.NASDAQ_ITCH50.gz")
+	if err := os.WriteFile(path, gzipMessages(t, testMessages()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config Configuration
+	}{
+		{"sequential", Configuration{LengthFieldPrefixed: true}},
+		{"decompress ahead", Configuration{LengthFieldPrefixed: true, DecompressAhead: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFile(path, tt.config)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+
+			if !cmp.Equal(got, testMessages()) {
+				t.Errorf("%v", cmp.Diff(testMessages(), got))
+			}
+		})
+	}
+}
+
+func TestDecoder_GzipMultistream(t *testing.T) {
+	// Concatenated gzip members decompress to the concatenation of their contents
+	data := append(gzipMessages(t, testMessages()), gzipMessages(t, testMessages())...)
+	want := append(testMessages(), testMessages()...)
+
+	d := NewDecoder(bytes.NewReader(data), Configuration{LengthFieldPrefixed: true, DecompressAhead: true})
+	defer d.Close()
+
+	got := []ItchMessage{}
+	for m, err := range d.All() {
+		if err != nil {
+			t.Fatalf("Decoder.All() error = %v", err)
+		}
+		got = append(got, m)
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("%v", cmp.Diff(want, got))
+	}
+}
+
+func TestDecoder_GzipInvalid(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte{0x1f, 0x8b, 0, 0}), Configuration{LengthFieldPrefixed: true})
+
+	if _, err := d.Next(); err == nil || err == io.EOF {
+		t.Errorf("Decoder.Next() error = %v, want gzip header error", err)
+	}
+}
+
+func TestReadAhead(t *testing.T) {
+	want := bytes.Repeat([]byte("ITCH"), readAheadBlockSize)
+
+	ra := newReadAhead(bytes.NewReader(want))
+	defer ra.Close()
+
+	got, err := io.ReadAll(ra)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("ReadAll() returned %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestReadAhead_Close(t *testing.T) {
+	ra := newReadAhead(bytes.NewReader(make([]byte, 16*readAheadBlockSize)))
+
+	if err := ra.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Buffered blocks can still be read, after which the reader reports it was closed
+	if _, err := io.ReadAll(ra); err != io.ErrClosedPipe {
+		t.Errorf("ReadAll() error = %v, want %v", err, io.ErrClosedPipe)
+	}
+}
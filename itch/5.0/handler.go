@@ -0,0 +1,322 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"errors"
+	"io"
+	"reflect"
+)
+
+// Handler receives decoded ITCH messages with one method per message type. Embed NopHandler
+// to only implement the methods for the message types you are interested in.
+type Handler interface {
+	OnSystemEvent(SystemEvent)
+	OnStockDirectory(StockDirectory)
+	OnStockTradingAction(StockTradingAction)
+	OnRegSho(RegSho)
+	OnParticipantPosition(ParticipantPosition)
+	OnMwcbLevel(MwcbLevel)
+	OnMwcbStatus(MwcbStatus)
+	OnIpoQuotation(IpoQuotation)
+	OnLuldCollar(LuldCollar)
+	OnOperationalHalt(OperationalHalt)
+	OnOrderAdd(OrderAdd)
+	OnOrderAddAttributed(OrderAddAttributed)
+	OnOrderExecuted(OrderExecuted)
+	OnOrderExecutedPrice(OrderExecutedPrice)
+	OnOrderCancel(OrderCancel)
+	OnOrderDelete(OrderDelete)
+	OnOrderReplace(OrderReplace)
+	OnTradeNonCross(TradeNonCross)
+	OnTradeCross(TradeCross)
+	OnTradeBroken(TradeBroken)
+	OnNoii(Noii)
+	OnRpii(Rpii)
+}
+
+// NopHandler implements Handler and ignores every message
+type NopHandler struct{}
+
+func (NopHandler) OnSystemEvent(SystemEvent)                 {}
+func (NopHandler) OnStockDirectory(StockDirectory)           {}
+func (NopHandler) OnStockTradingAction(StockTradingAction)   {}
+func (NopHandler) OnRegSho(RegSho)                           {}
+func (NopHandler) OnParticipantPosition(ParticipantPosition) {}
+func (NopHandler) OnMwcbLevel(MwcbLevel)                     {}
+func (NopHandler) OnMwcbStatus(MwcbStatus)                   {}
+func (NopHandler) OnIpoQuotation(IpoQuotation)               {}
+func (NopHandler) OnLuldCollar(LuldCollar)                   {}
+func (NopHandler) OnOperationalHalt(OperationalHalt)         {}
+func (NopHandler) OnOrderAdd(OrderAdd)                       {}
+func (NopHandler) OnOrderAddAttributed(OrderAddAttributed)   {}
+func (NopHandler) OnOrderExecuted(OrderExecuted)             {}
+func (NopHandler) OnOrderExecutedPrice(OrderExecutedPrice)   {}
+func (NopHandler) OnOrderCancel(OrderCancel)                 {}
+func (NopHandler) OnOrderDelete(OrderDelete)                 {}
+func (NopHandler) OnOrderReplace(OrderReplace)               {}
+func (NopHandler) OnTradeNonCross(TradeNonCross)             {}
+func (NopHandler) OnTradeCross(TradeCross)                   {}
+func (NopHandler) OnTradeBroken(TradeBroken)                 {}
+func (NopHandler) OnNoii(Noii)                               {}
+func (NopHandler) OnRpii(Rpii)                               {}
+
+// Dispatch calls the handler method matching the type of msg. Pointers to messages, e.g. *OrderAdd, are
+// dereferenced and dispatched as their value
+func Dispatch(msg ItchMessage, handler Handler) error {
+	switch m := msg.(type) {
+	case SystemEvent:
+		handler.OnSystemEvent(m)
+	case StockDirectory:
+		handler.OnStockDirectory(m)
+	case StockTradingAction:
+		handler.OnStockTradingAction(m)
+	case RegSho:
+		handler.OnRegSho(m)
+	case ParticipantPosition:
+		handler.OnParticipantPosition(m)
+	case MwcbLevel:
+		handler.OnMwcbLevel(m)
+	case MwcbStatus:
+		handler.OnMwcbStatus(m)
+	case IpoQuotation:
+		handler.OnIpoQuotation(m)
+	case LuldCollar:
+		handler.OnLuldCollar(m)
+	case OperationalHalt:
+		handler.OnOperationalHalt(m)
+	case OrderAdd:
+		handler.OnOrderAdd(m)
+	case OrderAddAttributed:
+		handler.OnOrderAddAttributed(m)
+	case OrderExecuted:
+		handler.OnOrderExecuted(m)
+	case OrderExecutedPrice:
+		handler.OnOrderExecutedPrice(m)
+	case OrderCancel:
+		handler.OnOrderCancel(m)
+	case OrderDelete:
+		handler.OnOrderDelete(m)
+	case OrderReplace:
+		handler.OnOrderReplace(m)
+	case TradeNonCross:
+		handler.OnTradeNonCross(m)
+	case TradeCross:
+		handler.OnTradeCross(m)
+	case TradeBroken:
+		handler.OnTradeBroken(m)
+	case Noii:
+		handler.OnNoii(m)
+	case Rpii:
+		handler.OnRpii(m)
+	default:
+		if msg == nil {
+			return NewInvalidPacketType(0)
+		}
+		if m, ok := indirectMessage(msg); ok {
+			return Dispatch(m, handler)
+		}
+		return NewUnsupportedMessage(msg)
+	}
+
+	return nil
+}
+
+// indirectMessage returns the message a non-nil pointer message points to
+func indirectMessage(msg ItchMessage) (ItchMessage, bool) {
+	v := reflect.ValueOf(msg)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return nil, false
+	}
+
+	m, ok := v.Elem().Interface().(ItchMessage)
+	return m, ok
+}
+
+// DecodeTo decodes every message from reader and passes it to handler. Messages are never boxed into
+// an ItchMessage, so this is the fastest way to consume a feed. Any errors parsing a message will be
+// joined together and returned after parsing all messages.
+func DecodeTo(reader io.Reader, config Configuration, handler Handler) error {
+	d := NewDecoder(reader, config)
+	defer d.Close()
+
+	return d.DecodeTo(handler)
+}
+
+// DecodeTo decodes the remaining messages and passes each to handler. Errors parsing a message are handled
+// according to Configuration.ErrorPolicy, by default they are joined together and returned after parsing all messages.
+func (d *Decoder) DecodeTo(handler Handler) error {
+	allErrs := error(nil)
+
+	for {
+		data, err := d.readFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			allErrs = errors.Join(allErrs, err)
+			if d.err != nil {
+				return allErrs
+			}
+			continue
+		}
+
+		if err := dispatchData(data, handler, d.symbols); err != nil {
+			allErrs = errors.Join(allErrs, d.parseError(data, err))
+			if d.err != nil {
+				return allErrs
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// dispatchData parses a single ITCH message and passes it to the matching handler method. The handler
+// is not called if the message fails to parse. symbols may be nil, in which case alpha fields are not interned
+func dispatchData(data []byte, handler Handler, symbols symbolTable) error {
+	if len(data) == 0 {
+		return NewInvalidPacketSize(1, 0)
+	}
+
+	switch data[0] {
+	case MESSAGE_SYSTEM_EVENT:
+		var m SystemEvent
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnSystemEvent(m)
+	case MESSAGE_STOCK_DIRECTORY:
+		var m StockDirectory
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnStockDirectory(m)
+	case MESSAGE_STOCK_TRADING_ACTION:
+		var m StockTradingAction
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnStockTradingAction(m)
+	case MESSAGE_REG_SHO:
+		var m RegSho
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnRegSho(m)
+	case MESSAGE_PARTICIPANT_POSITION:
+		var m ParticipantPosition
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnParticipantPosition(m)
+	case MESSAGE_MWCB_LEVEL:
+		var m MwcbLevel
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnMwcbLevel(m)
+	case MESSAGE_MWCB_STATUS:
+		var m MwcbStatus
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnMwcbStatus(m)
+	case MESSAGE_IPO_QUOTATION:
+		var m IpoQuotation
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnIpoQuotation(m)
+	case MESSAGE_LULD_COLLAR:
+		var m LuldCollar
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnLuldCollar(m)
+	case MESSAGE_OPERATIONAL_HALT:
+		var m OperationalHalt
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnOperationalHalt(m)
+	case MESSAGE_ORDER_ADD:
+		var m OrderAdd
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnOrderAdd(m)
+	case MESSAGE_ORDER_ADD_ATTRIBUTED:
+		var m OrderAddAttributed
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnOrderAddAttributed(m)
+	case MESSAGE_ORDER_EXECUTED:
+		var m OrderExecuted
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnOrderExecuted(m)
+	case MESSAGE_ORDER_EXECUTED_PRICE:
+		var m OrderExecutedPrice
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnOrderExecutedPrice(m)
+	case MESSAGE_ORDER_CANCEL:
+		var m OrderCancel
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnOrderCancel(m)
+	case MESSAGE_ORDER_DELETE:
+		var m OrderDelete
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnOrderDelete(m)
+	case MESSAGE_ORDER_REPLACE:
+		var m OrderReplace
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnOrderReplace(m)
+	case MESSAGE_TRADE_NON_CROSS:
+		var m TradeNonCross
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnTradeNonCross(m)
+	case MESSAGE_TRADE_CROSS:
+		var m TradeCross
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnTradeCross(m)
+	case MESSAGE_TRADE_BROKEN:
+		var m TradeBroken
+		if err := m.UnmarshalBinary(data); err != nil {
+			return err
+		}
+		handler.OnTradeBroken(m)
+	case MESSAGE_NOII:
+		var m Noii
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnNoii(m)
+	case MESSAGE_RPII:
+		var m Rpii
+		if err := m.decode(data, symbols); err != nil {
+			return err
+		}
+		handler.OnRpii(m)
+	default:
+		return NewInvalidPacketType(data[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type recordingHandler struct {
+	NopHandler
+	messages []ItchMessage
+}
+
+func (h *recordingHandler) OnSystemEvent(m SystemEvent)     { h.messages = append(h.messages, m) }
+func (h *recordingHandler) OnOrderAdd(m OrderAdd)           { h.messages = append(h.messages, m) }
+func (h *recordingHandler) OnOrderExecuted(m OrderExecuted) { h.messages = append(h.messages, m) }
+func (h *recordingHandler) OnOrderDelete(m OrderDelete)     { h.messages = append(h.messages, m) }
+
+func TestDecodeTo(t *testing.T) {
+	h := &recordingHandler{}
+
+	err := DecodeTo(bytes.NewReader(encodeMessages(testMessages(), true)), Configuration{LengthFieldPrefixed: true}, h)
+	if err != nil {
+		t.Fatalf("DecodeTo() error = %v", err)
+	}
+
+	if !cmp.Equal(h.messages, testMessages()) {
+		t.Errorf("%v", cmp.Diff(testMessages(), h.messages))
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	h := &recordingHandler{}
+
+	for _, m := range testMessages() {
+		if err := Dispatch(m, h); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+	}
+
+	if !cmp.Equal(h.messages, testMessages()) {
+		t.Errorf("%v", cmp.Diff(testMessages(), h.messages))
+	}
+
+	if err := Dispatch(nil, h); err == nil {
+		t.Errorf("Dispatch(nil) expected error")
+	}
+}
+
+func TestDispatch_Pointer(t *testing.T) {
+	h := &recordingHandler{}
+
+	for _, m := range testMessages() {
+		p := reflect.New(reflect.TypeOf(m))
+		p.Elem().Set(reflect.ValueOf(m))
+
+		if err := Dispatch(p.Interface().(ItchMessage), h); err != nil {
+			t.Fatalf("Dispatch(%T) error = %v", p.Interface(), err)
+		}
+	}
+
+	if !cmp.Equal(h.messages, testMessages()) {
+		t.Errorf("%v", cmp.Diff(testMessages(), h.messages))
+	}
+
+	var nilOrder *OrderAdd
+	if err := Dispatch(nilOrder, h); !errors.As(err, &ErrUnsupportedMessage{}) {
+		t.Errorf("Dispatch((*OrderAdd)(nil)) error = %v, want ErrUnsupportedMessage", err)
+	}
+}
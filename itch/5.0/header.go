@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// headerSize is the size of the fields at the start of every ITCH message
+const headerSize = 11
+
+// Header holds the fields common to every ITCH message, see ItchMessage.Header
+type Header struct {
+	Type           uint8
+	StockLocate    uint16
+	TrackingNumber uint16
+	Timestamp      time.Duration
+}
+
+// ParseHeader reads the common fields at the start of a message without decoding the rest of it
+func ParseHeader(data []byte) (Header, error) {
+	if len(data) < headerSize {
+		return Header{}, NewInvalidPacketSize(headerSize, len(data))
+	}
+
+	return parseHeader(data), nil
+}
+
+func parseHeader(data []byte) Header {
+	return Header{
+		Type:           data[0],
+		StockLocate:    binary.BigEndian.Uint16(data[1:3]),
+		TrackingNumber: binary.BigEndian.Uint16(data[3:5]),
+		Timestamp:      parseTimestamp(data[5:11]),
+	}
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHeader(t *testing.T) {
+	m := OrderExecuted{StockLocate: 3, TrackingNumber: 7, Timestamp: 9 * time.Hour, Reference: 2, Shares: 100, MatchNumber: 2}
+
+	got, err := ParseHeader(m.Bytes())
+	if err != nil {
+		t.Fatalf("ParseHeader() error = %v", err)
+	}
+
+	want := Header{Type: MESSAGE_ORDER_EXECUTED, StockLocate: 3, TrackingNumber: 7, Timestamp: 9 * time.Hour}
+	if got != want {
+		t.Errorf("ParseHeader() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseHeader(m.Bytes()[:10]); err == nil {
+		t.Errorf("ParseHeader() expected error for short data")
+	}
+}
+
+func TestItchMessage_Header(t *testing.T) {
+	for _, m := range allMessages() {
+		want, err := ParseHeader(m.Bytes())
+		if err != nil {
+			t.Fatalf("ParseHeader() error = %v", err)
+		}
+
+		if got := m.Header(); got != want {
+			t.Errorf("%c Header() = %v, want %v", m.Type(), got, want)
+		}
+	}
+}
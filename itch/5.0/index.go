@@ -0,0 +1,468 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	// indexMagic starts every index file and changes with the format
+	indexMagic = "ITCHIDX1"
+
+	DefaultIndexInterval = time.Second
+
+	// indexDay bounds the timestamps that can be indexed. Timestamps are time since midnight, so a message past
+	// the end of the day is corrupt and would otherwise add a bucket for every interval up to its timestamp
+	indexDay = 24 * time.Hour
+	// maxIndexBuckets bounds the number of buckets in a day, and so the smallest Interval
+	maxIndexBuckets = 1 << 24
+)
+
+// Index records where messages are in a length field prefixed ITCH file, so that part of the file can be read
+// without scanning it from the start. It holds the offset of the first message in every time bucket of
+// Interval, and for each stock locate the offset of its first message in every bucket it has messages in.
+//
+// An Index is built with BuildIndex and stored next to the ITCH file, see IndexPath.
+type Index struct {
+	// Interval is the length of each time bucket
+	Interval time.Duration
+	// Size of the indexed file, used to detect an index that is out of date
+	Size int64
+
+	// buckets holds the offset of the first message at or after each bucket's start time
+	buckets []int64
+	locates map[uint16][]indexEntry
+	symbols map[string]uint16
+}
+
+// indexEntry is the offset of the first message for a stock locate in a bucket
+type indexEntry struct {
+	bucket uint32
+	offset int64
+}
+
+// indexRange is a section of the ITCH file holding messages wanted from an Index
+type indexRange struct {
+	start int64
+	end   int64
+}
+
+type IndexOption func(x *Index)
+
+// WithIndexInterval sets the length of each time bucket. The default is one second and intervals that would
+// split a day into more than 2^24 buckets, i.e. shorter than about 5ms, are rejected
+func WithIndexInterval(interval time.Duration) IndexOption {
+	return func(x *Index) {
+		x.Interval = interval
+	}
+}
+
+// IndexPath returns where the index of an ITCH file is stored
+func IndexPath(path string) string {
+	return path + ".idx"
+}
+
+// BuildIndex scans a length field prefixed ITCH file and writes its Index to IndexPath(path). The file must
+// not be compressed, since a compressed file can't be read from an offset.
+func BuildIndex(path string, opts ...IndexOption) (*Index, error) {
+	x := &Index{
+		Interval: DefaultIndexInterval,
+		locates:  make(map[uint16][]indexEntry),
+		symbols:  make(map[string]uint16),
+	}
+
+	for _, opt := range opts {
+		opt(x)
+	}
+
+	if x.Interval <= 0 {
+		return nil, NewInvalidIndex("interval must be positive")
+	}
+	if indexDay/x.Interval > maxIndexBuckets {
+		return nil, NewInvalidIndex(fmt.Sprintf("interval must be at least %v", indexDay/maxIndexBuckets))
+	}
+
+	if err := x.scan(path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(IndexPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := x.WriteTo(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func (x *Index) scan(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := newBufferedReader(file, 1<<20)
+	if isGzip(reader) {
+		return NewInvalidIndex("cannot index a gzip compressed file")
+	}
+
+	d := NewDecoder(reader, Configuration{LengthFieldPrefixed: true})
+
+	for {
+		data, err := d.readFrame()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Unknown messages can be skipped over but the index can't be trusted if the file is truncated
+			if d.err != nil {
+				return err
+			}
+			continue
+		}
+
+		if len(data) < headerSize {
+			continue
+		}
+
+		header := parseHeader(data)
+		if header.Timestamp >= indexDay {
+			return NewInvalidIndex(fmt.Sprintf("message at offset %d has timestamp %v past the end of the day", d.frameOffset, header.Timestamp))
+		}
+
+		bucket := uint32(header.Timestamp / x.Interval)
+
+		for len(x.buckets) <= int(bucket) {
+			x.buckets = append(x.buckets, d.frameOffset)
+		}
+
+		entries := x.locates[header.StockLocate]
+		if len(entries) == 0 || entries[len(entries)-1].bucket < bucket {
+			x.locates[header.StockLocate] = append(entries, indexEntry{bucket: bucket, offset: d.frameOffset})
+		}
+
+		if header.Type == MESSAGE_STOCK_DIRECTORY && len(data) == stockDirectorySize {
+			x.symbols[d.symbols.get(data[11:19])] = header.StockLocate
+		}
+	}
+
+	x.Size = d.consumed
+
+	return nil
+}
+
+// LoadIndex reads the index of an ITCH file from IndexPath(path), checking that it matches the file
+func LoadIndex(path string) (*Index, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(IndexPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	x, err := ReadIndex(bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+
+	if x.Size != info.Size() {
+		return nil, NewInvalidIndex("file size does not match, the index is out of date")
+	}
+
+	return x, nil
+}
+
+// Locate returns the stock locate code of a stock symbol in the indexed file
+func (x *Index) Locate(stock string) (uint16, bool) {
+	locate, ok := x.symbols[stock]
+	return locate, ok
+}
+
+// Offset returns the offset of the first message in the bucket containing from, or the size of the file
+// if there are no messages at or after from
+func (x *Index) Offset(from time.Duration) int64 {
+	bucket := x.bucket(from)
+	if bucket >= len(x.buckets) {
+		return x.Size
+	}
+
+	return x.buckets[bucket]
+}
+
+func (x *Index) bucket(from time.Duration) int {
+	if from <= 0 {
+		return 0
+	}
+
+	return int(from / x.Interval)
+}
+
+// bucketEnd returns the offset where a bucket's messages end
+func (x *Index) bucketEnd(bucket uint32) int64 {
+	if int(bucket)+1 < len(x.buckets) {
+		return x.buckets[bucket+1]
+	}
+
+	return x.Size
+}
+
+// ranges returns the sections of the file holding the messages of the stock locates from a time onwards.
+// Overlapping and adjacent sections are merged
+func (x *Index) ranges(from time.Duration, stockLocates ...uint16) []indexRange {
+	bucket := x.bucket(from)
+
+	all := []indexRange{}
+	for _, locate := range stockLocates {
+		entries := x.locates[locate]
+
+		first := sort.Search(len(entries), func(i int) bool {
+			return int(entries[i].bucket) >= bucket
+		})
+
+		for _, e := range entries[first:] {
+			all = append(all, indexRange{start: e.offset, end: x.bucketEnd(e.bucket)})
+		}
+	}
+
+	slices.SortFunc(all, func(a, b indexRange) int {
+		return cmp.Compare(a.start, b.start)
+	})
+
+	ranges := []indexRange{}
+	for _, r := range all {
+		if n := len(ranges); n > 0 && ranges[n-1].end >= r.start {
+			ranges[n-1].end = max(ranges[n-1].end, r.end)
+			continue
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return ranges
+}
+
+// WriteTo writes the index in its binary format
+func (x *Index) WriteTo(w io.Writer) (int64, error) {
+	buf := []byte(indexMagic)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(x.Interval))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(x.Size))
+
+	symbols := make([]string, 0, len(x.symbols))
+	for symbol := range x.symbols {
+		symbols = append(symbols, symbol)
+	}
+	slices.Sort(symbols)
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(symbols)))
+	for _, symbol := range symbols {
+		buf = binary.BigEndian.AppendUint16(buf, x.symbols[symbol])
+		buf = appendAlpha(buf, symbol, 8)
+	}
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(x.buckets)))
+	for _, offset := range x.buckets {
+		buf = binary.BigEndian.AppendUint64(buf, uint64(offset))
+	}
+
+	locates := make([]uint16, 0, len(x.locates))
+	for locate := range x.locates {
+		locates = append(locates, locate)
+	}
+	slices.Sort(locates)
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(locates)))
+	for _, locate := range locates {
+		entries := x.locates[locate]
+
+		buf = binary.BigEndian.AppendUint16(buf, locate)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(entries)))
+		for _, e := range entries {
+			buf = binary.BigEndian.AppendUint32(buf, e.bucket)
+			buf = binary.BigEndian.AppendUint64(buf, uint64(e.offset))
+		}
+	}
+
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// ReadIndex reads an index written by Index.WriteTo
+func ReadIndex(reader io.Reader) (*Index, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	r := indexReader{data: data}
+
+	if magic := r.next(len(indexMagic)); r.err == nil && string(magic) != indexMagic {
+		return nil, NewInvalidIndex("not an ITCH index file")
+	}
+
+	x := &Index{
+		Interval: time.Duration(r.uint64()),
+		Size:     int64(r.uint64()),
+		locates:  make(map[uint16][]indexEntry),
+		symbols:  make(map[string]uint16),
+	}
+
+	if r.err == nil && x.Interval <= 0 {
+		return nil, NewInvalidIndex("interval must be positive")
+	}
+
+	for range r.count(2 + 8) {
+		locate := r.uint16()
+		x.symbols[symbolTable(nil).get(r.next(8))] = locate
+	}
+
+	x.buckets = make([]int64, r.count(8))
+	for i := range x.buckets {
+		x.buckets[i] = int64(r.uint64())
+	}
+
+	for range r.count(2 + 4) {
+		locate := r.uint16()
+
+		entries := make([]indexEntry, r.count(4+8))
+		if r.err != nil {
+			break
+		}
+		for i := range entries {
+			entries[i] = indexEntry{bucket: r.uint32(), offset: int64(r.uint64())}
+		}
+		x.locates[locate] = entries
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return x, nil
+}
+
+// indexReader reads the fields of an index, remembering the first error so it can be checked once at the end
+type indexReader struct {
+	data []byte
+	err  error
+}
+
+func (r *indexReader) next(n int) []byte {
+	if r.err == nil && len(r.data) < n {
+		r.err = NewInvalidIndex("unexpected end of index")
+	}
+	if r.err != nil {
+		return make([]byte, n)
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b
+}
+
+func (r *indexReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *indexReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.next(4))
+}
+
+func (r *indexReader) uint64() uint64 {
+	return binary.BigEndian.Uint64(r.next(8))
+}
+
+// count reads the number of items that follow, each at least size bytes. It is 0 once an error has occurred,
+// and an error if there aren't enough bytes left for that many items, so a corrupt count is never allocated
+func (r *indexReader) count(size int) int {
+	n := r.uint32()
+	if r.err != nil {
+		return 0
+	}
+
+	if uint64(n)*uint64(size) > uint64(len(r.data)) {
+		r.err = NewInvalidIndex("item count is larger than the rest of the index")
+		return 0
+	}
+
+	return int(n)
+}
+
+// OpenIndexed uses an Index to return a Decoder reading only the parts of a length field prefixed ITCH file
+// holding messages for stock from the time from onwards, along with messages with Stock Locate 0 such as
+// System Event. If stock is empty every message from the time from is read. If index is nil it is loaded
+// with LoadIndex.
+//
+// The Decoder applies config as well, with Symbols and StartTime set from stock and from. The offsets in any
+// DecodeError count only the bytes read, not the bytes skipped by the index. The Decoder should be closed
+// when finished.
+func OpenIndexed(path string, index *Index, stock string, from time.Duration, config Configuration) (*Decoder, error) {
+	if index == nil {
+		var err error
+		if index, err = LoadIndex(path); err != nil {
+			return nil, err
+		}
+	}
+
+	ranges := []indexRange{{start: index.Offset(from), end: index.Size}}
+
+	config.LengthFieldPrefixed = true
+	config.StartTime = max(config.StartTime, from)
+
+	locate, ok := uint16(0), false
+	if stock != "" {
+		config.Symbols = []string{stock}
+
+		// Messages for the whole market have Stock Locate 0
+		locate, ok = index.Locate(stock)
+		if ok {
+			ranges = index.ranges(from, 0, locate)
+		} else {
+			ranges = index.ranges(from, 0)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	readers := make([]io.Reader, 0, len(ranges))
+	for _, r := range ranges {
+		readers = append(readers, io.NewSectionReader(file, r.start, r.end-r.start))
+	}
+
+	d := NewDecoder(io.MultiReader(readers...), withTradingDate(config, path))
+	d.closers = append(d.closers, file)
+
+	// The Stock Directory message is usually before the ranges read so tell the filter the locate
+	if ok {
+		d.filter.locates[locate] = struct{}{}
+	}
+
+	return d, nil
+}
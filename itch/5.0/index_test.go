@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// indexMessages returns a day of orders for the stocks in filterMessages, one every 20 seconds from 09:30
+func indexMessages() []ItchMessage {
+	directory := filterMessages()
+
+	messages := slices.Clone(directory[:4])
+	for i := range 200 {
+		locate := uint16(i%3 + 1)
+		if i%7 == 0 {
+			// Only ERIC trades late in the day
+			locate = 3
+		}
+
+		messages = append(messages, OrderDelete{
+			StockLocate: locate,
+			Timestamp:   9*time.Hour + 30*time.Minute + time.Duration(i)*20*time.Second,
+			Reference:   uint64(i),
+		})
+	}
+
+	return append(messages, SystemEvent{Timestamp: 20 * time.Hour, EventCode: EVENT_END_MESSAGES})
+}
+
+func TestOpenIndexed(t *testing.T) {
+	path := writeTestFile(t, indexMessages())
+
+	index, err := BuildIndex(path, WithIndexInterval(time.Minute))
+	if err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		stock string
+		from  time.Duration
+	}{
+		{"stock", "AAPL", 0},
+		{"stock from time", "ERIC", 10*time.Hour + 5*time.Second},
+		{"from time", "", 10 * time.Hour},
+		{"after last message", "MSFT", 21 * time.Hour},
+		{"unknown stock", "TSLA", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Configuration{LengthFieldPrefixed: true, StartTime: tt.from}
+			if tt.stock != "" {
+				config.Symbols = []string{tt.stock}
+			}
+
+			want, err := ParseFile(path, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// A nil index is loaded from the file written by BuildIndex
+			for _, index := range []*Index{index, nil} {
+				d, err := OpenIndexed(path, index, tt.stock, tt.from, Configuration{})
+				if err != nil {
+					t.Fatalf("OpenIndexed() error = %v", err)
+				}
+
+				got, err := d.parseAll()
+				if err != nil {
+					t.Fatalf("parseAll() error = %v", err)
+				}
+				d.Close()
+
+				if !cmp.Equal(got, want) {
+					t.Errorf("%v", cmp.Diff(want, got))
+				}
+			}
+		})
+	}
+}
+
+func TestIndex_ranges(t *testing.T) {
+	path := writeTestFile(t, indexMessages())
+
+	index, err := BuildIndex(path, WithIndexInterval(time.Minute))
+	if err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+
+	locate, ok := index.Locate("ERIC")
+	if !ok {
+		t.Fatalf("Locate() did not find ERIC")
+	}
+
+	read := int64(0)
+	for _, r := range index.ranges(10*time.Hour, locate) {
+		read += r.end - r.start
+	}
+
+	if read == 0 || read >= index.Size-index.Offset(10*time.Hour) {
+		t.Errorf("ranges() read %d bytes of %d", read, index.Size-index.Offset(10*time.Hour))
+	}
+}
+
+func TestReadIndex(t *testing.T) {
+	path := writeTestFile(t, indexMessages())
+
+	index, err := BuildIndex(path)
+	if err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := index.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	data := buf.Bytes()
+
+	got, err := ReadIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadIndex() error = %v", err)
+	}
+
+	if !cmp.Equal(got, index, cmp.AllowUnexported(Index{}, indexEntry{})) {
+		t.Errorf("%v", cmp.Diff(index, got, cmp.AllowUnexported(Index{}, indexEntry{})))
+	}
+
+	// Counts larger than the rest of the index must not be allocated or looped over
+	header := binary.BigEndian.AppendUint64([]byte(indexMagic), uint64(time.Second))
+	header = binary.BigEndian.AppendUint64(header, 100)
+	hugeSymbols := binary.BigEndian.AppendUint32(slices.Clone(header), math.MaxUint32)
+	hugeBuckets := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(slices.Clone(header), 0), math.MaxUint32)
+	hugeEntries := binary.BigEndian.AppendUint32(slices.Clone(header), 0)
+	hugeEntries = binary.BigEndian.AppendUint32(hugeEntries, 0)
+	hugeEntries = binary.BigEndian.AppendUint32(hugeEntries, 1)
+	hugeEntries = binary.BigEndian.AppendUint16(hugeEntries, 1)
+	hugeEntries = binary.BigEndian.AppendUint32(hugeEntries, math.MaxUint32)
+
+	for _, invalid := range [][]byte{nil, []byte("NOTINDEX"), data[:len(data)-1], hugeSymbols, hugeBuckets, hugeEntries} {
+		var indexErr ErrInvalidIndex
+		if _, err := ReadIndex(bytes.NewReader(invalid)); !errors.As(err, &indexErr) {
+			t.Errorf("ReadIndex() error = %v, want ErrInvalidIndex", err)
+		}
+	}
+}
+
+func TestLoadIndex_OutOfDate(t *testing.T) {
+	path := writeTestFile(t, indexMessages())
+
+	if _, err := BuildIndex(path); err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+
+	if err := os.WriteFile(path, encodeMessages(testMessages(), true), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var indexErr ErrInvalidIndex
+	if _, err := LoadIndex(path); !errors.As(err, &indexErr) {
+		t.Errorf("LoadIndex() error = %v, want ErrInvalidIndex", err)
+	}
+}
+
+func TestBuildIndex_Gzip(t *testing.T) {
+	path := writeTestFile(t, nil)
+	if err := os.WriteFile(path, gzipMessages(t, testMessages()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var indexErr ErrInvalidIndex
+	if _, err := BuildIndex(path); !errors.As(err, &indexErr) {
+		t.Errorf("BuildIndex() error = %v, want ErrInvalidIndex", err)
+	}
+}
+
+func TestBuildIndex_Invalid(t *testing.T) {
+	late := indexMessages()
+	late = append(late, SystemEvent{EventCode: EVENT_END_MESSAGES, Timestamp: 25 * time.Hour})
+
+	tests := []struct {
+		name     string
+		messages []ItchMessage
+		opts     []IndexOption
+	}{
+		{"timestamp past end of day", late, nil},
+		{"interval too small", indexMessages(), []IndexOption{WithIndexInterval(time.Nanosecond)}},
+		{"interval not positive", indexMessages(), []IndexOption{WithIndexInterval(0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFile(t, tt.messages)
+
+			var indexErr ErrInvalidIndex
+			if _, err := BuildIndex(path, tt.opts...); !errors.As(err, &indexErr) {
+				t.Errorf("BuildIndex() error = %v, want ErrInvalidIndex", err)
+			}
+		})
+	}
+}
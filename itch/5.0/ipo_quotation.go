@@ -7,7 +7,7 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
+	"math"
 	"time"
 
 	"github.com/quagmt/udecimal"
@@ -25,39 +25,77 @@ const (
 )
 
 type IpoQuotation struct {
-	StockLocate    uint16
-	TrackingNumber uint16
-	Timestamp      time.Duration
-	Stock          string
-	ReleaseTime    time.Duration
-	Qualifier      ReleaseQualifier
-	Price          udecimal.Decimal // Price (4)
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	Timestamp      time.Duration    `json:"timestamp"`
+	Stock          string           `json:"stock"`
+	ReleaseTime    time.Duration    `json:"releaseTime"`
+	Qualifier      ReleaseQualifier `json:"qualifier"`
+	Price          udecimal.Decimal `json:"price"` // Price (4)
 }
 
 func (i IpoQuotation) Type() uint8 {
 	return MESSAGE_IPO_QUOTATION
 }
 
+func (i IpoQuotation) Header() Header {
+	return Header{Type: MESSAGE_IPO_QUOTATION, StockLocate: i.StockLocate, TrackingNumber: i.TrackingNumber, Timestamp: i.Timestamp}
+}
+
+func (i IpoQuotation) Symbol() Symbol {
+	return NewSymbol(i.Stock)
+}
+
 func (i IpoQuotation) Bytes() []byte {
-	data := make([]byte, ipoQuotationSize)
+	return i.appendBinary(make([]byte, 0, ipoQuotationSize))
+}
 
-	data[0] = MESSAGE_IPO_QUOTATION
-	binary.BigEndian.PutUint16(data[1:3], i.StockLocate)
+func (i IpoQuotation) MarshalBinary() ([]byte, error) {
+	return i.AppendBinary(make([]byte, 0, ipoQuotationSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(i.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], i.TrackingNumber)
+func (i IpoQuotation) AppendBinary(dst []byte) ([]byte, error) {
+	if err := i.validate(); err != nil {
+		return dst, err
+	}
 
-	copy(data[11:19], []byte(fmt.Sprintf("%-8s", i.Stock)))
+	return i.appendBinary(dst), nil
+}
 
-	binary.BigEndian.PutUint32(data[19:23], uint32(i.ReleaseTime.Seconds()))
+func (i IpoQuotation) MarshalJSON() ([]byte, error) {
+	type message IpoQuotation
+	return marshalMessageJSON(MESSAGE_IPO_QUOTATION, message(i))
+}
 
-	data[23] = byte(i.Qualifier)
+func (i *IpoQuotation) UnmarshalJSON(data []byte) error {
+	type message IpoQuotation
+	return unmarshalMessageJSON(data, MESSAGE_IPO_QUOTATION, (*message)(i))
+}
+
+func (i IpoQuotation) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_IPO_QUOTATION, i.StockLocate, i.TrackingNumber, i.Timestamp)
+	dst = appendAlpha(dst, i.Stock, 8)
+	dst = binary.BigEndian.AppendUint32(dst, uint32(i.ReleaseTime/time.Second))
+	dst = append(dst, byte(i.Qualifier))
+	dst = appendPrice(dst, i.Price, 4)
+
+	return dst
+}
 
-	price, _ := priceToBytes(i.Price, 4)
-	copy(data[24:28], price)
+func (i IpoQuotation) validate() error {
+	v := validator{messageType: MESSAGE_IPO_QUOTATION}
+	v.timestamp("Timestamp", i.Timestamp)
+	v.alpha("Stock", i.Stock, 8)
 
-	return data
+	// Release Time is a whole number of seconds since midnight
+	if i.ReleaseTime < 0 || i.ReleaseTime%time.Second != 0 || i.ReleaseTime/time.Second > math.MaxUint32 {
+		v.fail("ReleaseTime", i.ReleaseTime)
+	}
+
+	v.enum("Qualifier", i.Qualifier.valid(), uint8(i.Qualifier))
+	v.price("Price", i.Price, 4)
+
+	return v.err
 }
 
 func MakeIpoQuotation(stockLocate, trackingNumber uint16, timestamp time.Duration, stock string, releaseTime time.Duration, qualifier ReleaseQualifier, price udecimal.Decimal) IpoQuotation {
@@ -73,31 +111,41 @@ func MakeIpoQuotation(stockLocate, trackingNumber uint16, timestamp time.Duratio
 }
 
 func ParseIpoQuotation(data []byte) (IpoQuotation, error) {
+	var i IpoQuotation
+	err := i.UnmarshalBinary(data)
+	return i, err
+}
+
+func (i *IpoQuotation) UnmarshalBinary(data []byte) error {
+	return i.decode(data, nil)
+}
+
+func (i *IpoQuotation) decode(data []byte, symbols symbolTable) error {
 	if len(data) != ipoQuotationSize {
-		return IpoQuotation{}, NewInvalidPacketSize(ipoQuotationSize, len(data))
+		return NewInvalidPacketSize(ipoQuotationSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	stock := strings.TrimSpace(string(data[11:19]))
+	stock := symbols.get(data[11:19])
 
 	releaseTime := binary.BigEndian.Uint32(data[19:23])
 
 	price, _ := bytesToPrice(data[24:28], 4)
 
-	return IpoQuotation{
+	*i = IpoQuotation{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Stock:          stock,
 		ReleaseTime:    time.Duration(uint64(releaseTime) * uint64(time.Second)),
 		Qualifier:      ReleaseQualifier(data[23]),
 		Price:          price,
-	}, nil
+	}
+
+	return nil
 }
 
 func (i IpoQuotation) String() string {
@@ -124,3 +172,20 @@ func (r ReleaseQualifier) String() string {
 
 	return "Unknown ReleaseQualifier"
 }
+
+func (r ReleaseQualifier) valid() bool {
+	switch r {
+	case QUALIFIER_ANTICIPATED, QUALIFER_CANCELED_POSTPONED:
+		return true
+	}
+
+	return false
+}
+
+func (r ReleaseQualifier) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(r)), r.String())
+}
+
+func (r *ReleaseQualifier) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(r))
+}
@@ -8,9 +8,7 @@ package itch
 import (
 	"bufio"
 	"errors"
-	"io"
 	"os"
-	"slices"
 )
 
 const (
@@ -62,11 +60,14 @@ var (
 )
 
 type ItchMessage interface {
+	// Bytes encodes the message without validating it. Fields that do not fit are truncated
 	Bytes() []byte
 	Type() uint8
+	// Header returns the fields common to every message type
+	Header() Header
 }
 
-// ParseFile parses ITCH messages from an uncompressed file. It uses ParseReader internally
+// ParseFile parses ITCH messages from a file, decompressing it if it is gzip compressed. It uses ParseReader internally
 func ParseFile(path string, config Configuration) ([]ItchMessage, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -74,138 +75,30 @@ func ParseFile(path string, config Configuration) ([]ItchMessage, error) {
 	}
 	defer file.Close()
 
-	var reader *bufio.Reader
-	if config.ReadBufferSize > 0 {
-		reader = bufio.NewReaderSize(file, int(config.ReadBufferSize))
-	} else {
-		reader = bufio.NewReader(file)
-	}
-
-	return ParseReader(reader, config)
+	return ParseReader(newBufferedReader(file, config.ReadBufferSize), config)
 }
 
-// ParseReader parses ITCH messages from a bufio.Reader. Any errors parsing a message will
-// be joined together and returned after parsing all messages.
+// ParseReader parses ITCH messages from a bufio.Reader. Errors parsing a message are handled according to
+// Configuration.ErrorPolicy, by default they are joined together and returned after parsing all messages.
+//
+// All messages are accumulated in memory. Use a Decoder to process messages one at a time instead.
 func ParseReader(reader *bufio.Reader, config Configuration) ([]ItchMessage, error) {
-	messages := []ItchMessage{}
-
-	allErrs := error(nil)
-
-	for {
-		if config.MaxMessages > 0 && len(messages) >= config.MaxMessages {
-			break
-		}
-
-		var msgLength int
-
-		if config.LengthFieldPrefixed {
-			msgLengthBuffer, err := reader.Peek(2)
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				return messages, err
-			}
-
-			_, err = reader.Discard(2)
-			if err != nil {
-				return messages, err
-			}
-
-			msgLength = int(uint16(msgLengthBuffer[1]) | uint16(msgLengthBuffer[0])<<8)
-
-		} else {
-			msgTypeBuffer, err := reader.Peek(1)
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				return messages, err
-			}
-
-			msgLength = getMessageSize(msgTypeBuffer[0])
-		}
-
-		data := make([]byte, msgLength)
-
-		n, err := io.ReadFull(reader, data)
-		if n == 0 || err == io.EOF {
-			break
-		}
-		if err != nil {
-			return messages, err
-		}
-
-		// If user configured MessageTypes then only parse messages they want
-		if len(config.MessageTypes) != 0 {
-			if !slices.Contains(config.MessageTypes, data[0]) {
-				continue
-			}
-		}
-
-		m, err := parseData(data[0], data)
-		if err != nil {
-			allErrs = errors.Join(allErrs, err)
-		}
-
-		messages = append(messages, m)
-	}
+	d := NewDecoder(reader, config)
+	defer d.Close()
 
-	return messages, allErrs
+	return d.parseAll()
 }
 
-// ParseMany parses multiple ITCH messages from byte data already loaded into memory.
-// Any errors parsing a message will be joined together and returned after parsing all messages.
+// ParseMany parses multiple ITCH messages from byte data already loaded into memory. Messages are decoded directly
+// from data without copying it. Errors parsing a message are handled according to Configuration.ErrorPolicy, by
+// default they are joined together and returned after parsing all messages.
 func ParseMany(data []byte, config Configuration) ([]ItchMessage, error) {
-	messages := []ItchMessage{}
-
-	dp := 0
-
-	allErrs := error(nil)
-
-	for {
-		if dp >= len(data) {
-			// Reached end of data
-			break
-		}
-
-		if config.MaxMessages > 0 && len(messages) >= config.MaxMessages {
-			break
-		}
-
-		var msgLength int
-		if config.LengthFieldPrefixed {
-			msgLength = int(uint16(data[dp+1]) | uint16(data[dp])<<8)
-		} else {
-			msgLength = getMessageSize(data[dp])
-		}
-
-		startOfMessage := dp
-		endOfMessage := startOfMessage + msgLength
-
-		dp += int(msgLength)
-
-		// If user configured MessageTypes then only parse messages they want
-		if len(config.MessageTypes) != 0 {
-			if !slices.Contains(config.MessageTypes, data[startOfMessage]) {
-				continue
-			}
-		}
-
-		m, err := parseData(data[startOfMessage], data[startOfMessage:endOfMessage])
-		if err != nil {
-			allErrs = errors.Join(allErrs, err)
-		}
-
-		messages = append(messages, m)
-	}
-
-	return messages, allErrs
+	return newBytesDecoder(data, config).parseAll()
 }
 
 // Parse will parse a single ITCH message - it should not have a length field prefixed, just give the actual ITCH message
 func Parse(data []byte) (ItchMessage, error) {
-	return parseData(data[0], data)
+	return parseData(data, nil)
 }
 
 func getMessageSize(msgType byte) int {
@@ -259,53 +152,102 @@ func getMessageSize(msgType byte) int {
 	}
 }
 
-func parseData(msgType byte, data []byte) (ItchMessage, error) {
-	switch msgType {
+// parseData parses a single ITCH message. symbols may be nil, in which case alpha fields are not interned
+func parseData(data []byte, symbols symbolTable) (ItchMessage, error) {
+	if len(data) == 0 {
+		return nil, NewInvalidPacketSize(1, 0)
+	}
+
+	switch data[0] {
 	case MESSAGE_SYSTEM_EVENT:
-		return ParseSystemEvent(data)
+		var m SystemEvent
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_STOCK_DIRECTORY:
-		return ParseStockDirectory(data)
+		var m StockDirectory
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_STOCK_TRADING_ACTION:
-		return ParseStockTradingAction(data)
+		var m StockTradingAction
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_REG_SHO:
-		return ParseRegSho(data)
+		var m RegSho
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_PARTICIPANT_POSITION:
-		return ParseParticipantPosition(data)
+		var m ParticipantPosition
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_MWCB_LEVEL:
-		return ParseMwcbLevel(data)
+		var m MwcbLevel
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_MWCB_STATUS:
-		return ParseMwcbStatus(data)
+		var m MwcbStatus
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_IPO_QUOTATION:
-		return ParseIpoQuotation(data)
+		var m IpoQuotation
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_LULD_COLLAR:
-		return ParseLuldCollar(data)
+		var m LuldCollar
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_OPERATIONAL_HALT:
-		return ParseOperationalHalt(data)
+		var m OperationalHalt
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_ORDER_ADD:
-		return ParseOrderAdd(data)
+		var m OrderAdd
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_ORDER_ADD_ATTRIBUTED:
-		return ParseOrderAddAttributed(data)
+		var m OrderAddAttributed
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_ORDER_EXECUTED:
-		return ParseOrderExecuted(data)
+		var m OrderExecuted
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_ORDER_EXECUTED_PRICE:
-		return ParseOrderExecutedPrice(data)
+		var m OrderExecutedPrice
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_ORDER_CANCEL:
-		return ParseOrderCancel(data)
+		var m OrderCancel
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_ORDER_DELETE:
-		return ParseOrderDelete(data)
+		var m OrderDelete
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_ORDER_REPLACE:
-		return ParseOrderReplace(data)
+		var m OrderReplace
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_TRADE_NON_CROSS:
-		return ParseTradeNonCross(data)
+		var m TradeNonCross
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_TRADE_CROSS:
-		return ParseTradeCross(data)
+		var m TradeCross
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_TRADE_BROKEN:
-		return ParseTradeBroken(data)
+		var m TradeBroken
+		err := m.UnmarshalBinary(data)
+		return m, err
 	case MESSAGE_NOII:
-		return ParseNoii(data)
+		var m Noii
+		err := m.decode(data, symbols)
+		return m, err
 	case MESSAGE_RPII:
-		return ParseRpii(data)
+		var m Rpii
+		err := m.decode(data, symbols)
+		return m, err
 	default:
-		return nil, NewInvalidPacketType(msgType)
+		return nil, NewInvalidPacketType(data[0])
 	}
 }
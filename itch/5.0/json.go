@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// enumJSON is how enum fields are encoded in JSON, as their ITCH code and a description of it
+type enumJSON struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+func marshalEnumJSON(code, description string) ([]byte, error) {
+	return json.Marshal(enumJSON{Code: code, Description: description})
+}
+
+// unmarshalEnumJSON returns the code of an enum encoded by marshalEnumJSON. The code on its own as a string
+// is accepted too
+func unmarshalEnumJSON(data []byte) (string, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte{'"'}) {
+		var code string
+		err := json.Unmarshal(data, &code)
+		return code, err
+	}
+
+	var e enumJSON
+	err := json.Unmarshal(data, &e)
+	return e.Code, err
+}
+
+// unmarshalCharEnumJSON decodes an enum with a single character code
+func unmarshalCharEnumJSON(data []byte, value *uint8) error {
+	code, err := unmarshalEnumJSON(data)
+	if err != nil {
+		return err
+	}
+
+	if len(code) != 1 {
+		return fmt.Errorf("invalid enum code=%q", code)
+	}
+	*value = code[0]
+
+	return nil
+}
+
+// messageTypeJSON holds the type discriminator of a message encoded in JSON
+type messageTypeJSON struct {
+	Type *string `json:"type"`
+}
+
+// marshalMessageJSON encodes a message, which must be a struct without its own MarshalJSON method, with a
+// type field added first so it can be decoded by UnmarshalJSONMessage
+func marshalMessageJSON(messageType uint8, message any) ([]byte, error) {
+	fields, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, len(fields)+12)
+	data = append(data, `{"type":"`...)
+	data = append(data, messageType)
+	data = append(data, '"')
+
+	if len(fields) > 2 {
+		data = append(data, ',')
+	}
+
+	return append(data, fields[1:]...), nil
+}
+
+// unmarshalMessageJSON decodes a message encoded by marshalMessageJSON, checking the type field if it is present
+func unmarshalMessageJSON(data []byte, messageType uint8, message any) error {
+	var t messageTypeJSON
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+
+	if t.Type != nil && *t.Type != string(rune(messageType)) {
+		return fmt.Errorf("json type=%q does not match message type=%c", *t.Type, messageType)
+	}
+
+	return json.Unmarshal(data, message)
+}
+
+// UnmarshalJSONMessage decodes a message encoded with MarshalJSON, using its type field to choose the message type
+func UnmarshalJSONMessage(data []byte) (ItchMessage, error) {
+	var t messageTypeJSON
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+
+	if t.Type == nil || len(*t.Type) != 1 {
+		return nil, fmt.Errorf("json message has no valid type field")
+	}
+
+	switch messageType := (*t.Type)[0]; messageType {
+	case MESSAGE_SYSTEM_EVENT:
+		return unmarshalJSONAs[SystemEvent](data)
+	case MESSAGE_STOCK_DIRECTORY:
+		return unmarshalJSONAs[StockDirectory](data)
+	case MESSAGE_STOCK_TRADING_ACTION:
+		return unmarshalJSONAs[StockTradingAction](data)
+	case MESSAGE_REG_SHO:
+		return unmarshalJSONAs[RegSho](data)
+	case MESSAGE_PARTICIPANT_POSITION:
+		return unmarshalJSONAs[ParticipantPosition](data)
+	case MESSAGE_MWCB_LEVEL:
+		return unmarshalJSONAs[MwcbLevel](data)
+	case MESSAGE_MWCB_STATUS:
+		return unmarshalJSONAs[MwcbStatus](data)
+	case MESSAGE_IPO_QUOTATION:
+		return unmarshalJSONAs[IpoQuotation](data)
+	case MESSAGE_LULD_COLLAR:
+		return unmarshalJSONAs[LuldCollar](data)
+	case MESSAGE_OPERATIONAL_HALT:
+		return unmarshalJSONAs[OperationalHalt](data)
+	case MESSAGE_ORDER_ADD:
+		return unmarshalJSONAs[OrderAdd](data)
+	case MESSAGE_ORDER_ADD_ATTRIBUTED:
+		return unmarshalJSONAs[OrderAddAttributed](data)
+	case MESSAGE_ORDER_EXECUTED:
+		return unmarshalJSONAs[OrderExecuted](data)
+	case MESSAGE_ORDER_EXECUTED_PRICE:
+		return unmarshalJSONAs[OrderExecutedPrice](data)
+	case MESSAGE_ORDER_CANCEL:
+		return unmarshalJSONAs[OrderCancel](data)
+	case MESSAGE_ORDER_DELETE:
+		return unmarshalJSONAs[OrderDelete](data)
+	case MESSAGE_ORDER_REPLACE:
+		return unmarshalJSONAs[OrderReplace](data)
+	case MESSAGE_TRADE_NON_CROSS:
+		return unmarshalJSONAs[TradeNonCross](data)
+	case MESSAGE_TRADE_CROSS:
+		return unmarshalJSONAs[TradeCross](data)
+	case MESSAGE_TRADE_BROKEN:
+		return unmarshalJSONAs[TradeBroken](data)
+	case MESSAGE_NOII:
+		return unmarshalJSONAs[Noii](data)
+	case MESSAGE_RPII:
+		return unmarshalJSONAs[Rpii](data)
+	default:
+		return nil, NewInvalidPacketType(messageType)
+	}
+}
+
+func unmarshalJSONAs[T ItchMessage](data []byte) (ItchMessage, error) {
+	var m T
+	err := json.Unmarshal(data, &m)
+	return m, err
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	got, err := json.Marshal(testMessages()[1])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{"type":"A","stock":"ERIC","timestamp":14400000000000,"reference":13662,"shares":6000,"price":"7.93",` +
+		`"stockLocate":2528,"trackingNumber":0,"orderIndicator":{"code":"B","description":"Buy"}}`
+	if string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestMarshalJSON_Enums(t *testing.T) {
+	tests := []struct {
+		msg  ItchMessage
+		want string
+	}{
+		{MwcbStatus{BreachedLevel: BREACHED_LEVEL_2}, `"breachedLevel":{"code":"2","description":"Level 2"}`},
+		{Noii{VariationIndicator: VARIATION_10_PERCENT}, `"variationIndicator":{"code":"A","description":"10% to 19.99%"}`},
+		{Noii{VariationIndicator: VARIATION_3_PERCENT}, `"variationIndicator":{"code":"3","description":"3% to 3.99%"}`},
+		{Noii{VariationIndicator: VARIATION_NOT_CALCULATED}, `"variationIndicator":{"code":" ","description":"Cannot be calculated"}`},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.msg)
+		if err != nil {
+			t.Fatalf("Marshal(%T) error = %v", tt.msg, err)
+		}
+		if !bytes.Contains(got, []byte(tt.want)) {
+			t.Errorf("Marshal(%T) = %s, want it to contain %s", tt.msg, got, tt.want)
+		}
+
+		m, err := UnmarshalJSONMessage(got)
+		if err != nil {
+			t.Fatalf("UnmarshalJSONMessage(%s) error = %v", got, err)
+		}
+		if !cmp.Equal(m, tt.msg) {
+			t.Errorf("%v", cmp.Diff(tt.msg, m))
+		}
+	}
+}
+
+func TestUnmarshalJSONMessage(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, m := range allMessages() {
+		if err := encoder.Encode(m); err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+	}
+
+	// Decode the JSON lines back into messages
+	got := []ItchMessage{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		m, err := UnmarshalJSONMessage(scanner.Bytes())
+		if err != nil {
+			t.Fatalf("UnmarshalJSONMessage(%s) error = %v", scanner.Bytes(), err)
+		}
+		got = append(got, m)
+	}
+
+	if want := allMessages(); !cmp.Equal(got, want) {
+		t.Errorf("%v", cmp.Diff(want, got))
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    SystemEvent
+		wantErr bool
+	}{
+		{"enum object", `{"type":"S","timestamp":1,"eventCode":{"code":"O","description":"Start of Messages"}}`, SystemEvent{Timestamp: 1, EventCode: EVENT_START_MESSAGES}, false},
+		{"enum code", `{"timestamp":1,"eventCode":"O"}`, SystemEvent{Timestamp: 1, EventCode: EVENT_START_MESSAGES}, false},
+		{"wrong type", `{"type":"A","timestamp":1}`, SystemEvent{}, true},
+		{"invalid enum code", `{"type":"S","eventCode":"OO"}`, SystemEvent{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got SystemEvent
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Unmarshal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, data := range []string{`{"timestamp":1}`, `{"type":"Z"}`, `[]`} {
+		if _, err := UnmarshalJSONMessage([]byte(data)); err == nil {
+			t.Errorf("UnmarshalJSONMessage(%s) expected error", data)
+		}
+	}
+}
@@ -7,77 +7,117 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/quagmt/udecimal"
 )
 
 type LuldCollar struct {
-	StockLocate    uint16
-	TrackingNumber uint16
-	Timestamp      time.Duration
-	Stock          string
-	ReferencePrice udecimal.Decimal // Price(4)
-	UpperPrice     udecimal.Decimal // Price(4)
-	LowerPrice     udecimal.Decimal // Price(4)
-	Extension      uint32
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	Timestamp      time.Duration    `json:"timestamp"`
+	Stock          string           `json:"stock"`
+	ReferencePrice udecimal.Decimal `json:"referencePrice"` // Price(4)
+	UpperPrice     udecimal.Decimal `json:"upperPrice"`     // Price(4)
+	LowerPrice     udecimal.Decimal `json:"lowerPrice"`     // Price(4)
+	Extension      uint32           `json:"extension"`
 }
 
 func (l LuldCollar) Type() uint8 {
 	return MESSAGE_LULD_COLLAR
 }
 
+func (l LuldCollar) Header() Header {
+	return Header{Type: MESSAGE_LULD_COLLAR, StockLocate: l.StockLocate, TrackingNumber: l.TrackingNumber, Timestamp: l.Timestamp}
+}
+
+func (l LuldCollar) Symbol() Symbol {
+	return NewSymbol(l.Stock)
+}
+
 func (l LuldCollar) Bytes() []byte {
-	data := make([]byte, luldSize)
+	return l.appendBinary(make([]byte, 0, luldSize))
+}
 
-	data[0] = MESSAGE_LULD_COLLAR
-	binary.BigEndian.PutUint16(data[1:3], l.StockLocate)
+func (l LuldCollar) MarshalBinary() ([]byte, error) {
+	return l.AppendBinary(make([]byte, 0, luldSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(l.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], l.TrackingNumber)
+func (l LuldCollar) AppendBinary(dst []byte) ([]byte, error) {
+	if err := l.validate(); err != nil {
+		return dst, err
+	}
 
-	copy(data[11:19], []byte(fmt.Sprintf("%-8s", l.Stock)))
+	return l.appendBinary(dst), nil
+}
 
-	refP, _ := priceToBytes(l.ReferencePrice, 4)
-	upP, _ := priceToBytes(l.UpperPrice, 4)
-	lowP, _ := priceToBytes(l.LowerPrice, 4)
+func (l LuldCollar) MarshalJSON() ([]byte, error) {
+	type message LuldCollar
+	return marshalMessageJSON(MESSAGE_LULD_COLLAR, message(l))
+}
 
-	copy(data[19:23], refP)
-	copy(data[23:27], upP)
-	copy(data[27:31], lowP)
+func (l *LuldCollar) UnmarshalJSON(data []byte) error {
+	type message LuldCollar
+	return unmarshalMessageJSON(data, MESSAGE_LULD_COLLAR, (*message)(l))
+}
 
-	binary.BigEndian.PutUint32(data[31:], l.Extension)
+func (l LuldCollar) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_LULD_COLLAR, l.StockLocate, l.TrackingNumber, l.Timestamp)
+	dst = appendAlpha(dst, l.Stock, 8)
+	dst = appendPrice(dst, l.ReferencePrice, 4)
+	dst = appendPrice(dst, l.UpperPrice, 4)
+	dst = appendPrice(dst, l.LowerPrice, 4)
+	dst = binary.BigEndian.AppendUint32(dst, l.Extension)
 
-	return data
+	return dst
+}
+
+func (l LuldCollar) validate() error {
+	v := validator{messageType: MESSAGE_LULD_COLLAR}
+	v.timestamp("Timestamp", l.Timestamp)
+	v.alpha("Stock", l.Stock, 8)
+	v.price("ReferencePrice", l.ReferencePrice, 4)
+	v.price("UpperPrice", l.UpperPrice, 4)
+	v.price("LowerPrice", l.LowerPrice, 4)
+
+	return v.err
 }
 
 func ParseLuldCollar(data []byte) (LuldCollar, error) {
+	var l LuldCollar
+	err := l.UnmarshalBinary(data)
+	return l, err
+}
+
+func (l *LuldCollar) UnmarshalBinary(data []byte) error {
+	return l.decode(data, nil)
+}
+
+func (l *LuldCollar) decode(data []byte, symbols symbolTable) error {
 	if len(data) != luldSize {
-		return LuldCollar{}, NewInvalidPacketSize(luldSize, len(data))
+		return NewInvalidPacketSize(luldSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	refP, _ := bytesToPrice(data[19:23], 4)
 	upP, _ := bytesToPrice(data[23:27], 4)
 	lowP, _ := bytesToPrice(data[27:31], 4)
 
-	return LuldCollar{
+	*l = LuldCollar{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
-		Stock:          strings.TrimSpace(string(data[11:19])),
+		Timestamp:      timestamp,
+		Stock:          symbols.get(data[11:19]),
 		ReferencePrice: refP,
 		UpperPrice:     upP,
 		LowerPrice:     lowP,
 		Extension:      binary.BigEndian.Uint32(data[31:]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (l LuldCollar) String() string {
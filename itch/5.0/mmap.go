@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import "os"
+
+// OpenMapped memory maps an ITCH file and returns a Decoder reading messages directly from the mapping
+// without copying them. The mapping is read only, which is safe because messages are decoded without
+// modifying their data. Decoded messages do not refer to the mapping so they remain valid after the
+// Decoder is closed.
+//
+// gzip compressed files are decompressed from the mapping. On platforms without mmap support the file is
+// read into memory instead. The returned Decoder should be closed when finished to unmap the file.
+func OpenMapped(path string, config Configuration) (*Decoder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// The mapping remains valid after the file is closed
+	defer file.Close()
+
+	m, err := mapFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	d := newBytesDecoder(m.data, withTradingDate(config, path))
+	d.closers = append(d.closers, m)
+
+	return d, nil
+}
+
+// ParseMapped parses ITCH messages from a memory mapped file, see OpenMapped. Any errors parsing a
+// message will be joined together and returned after parsing all messages.
+func ParseMapped(path string, config Configuration) ([]ItchMessage, error) {
+	d, err := OpenMapped(path, config)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	return d.parseAll()
+}
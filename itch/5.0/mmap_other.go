@@ -0,0 +1,31 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"io"
+	"os"
+)
+
+// mapping holds the file contents in memory on platforms without mmap support
+type mapping struct {
+	data []byte
+}
+
+func mapFile(file *os.File) (*mapping, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mapping{data: data}, nil
+}
+
+func (m *mapping) Close() error {
+	m.data = nil
+	return nil
+}
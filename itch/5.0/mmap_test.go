@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMapped(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		data   []byte
+		config Configuration
+		want   []ItchMessage
+	}{
+		{"length prefixed", encodeMessages(allMessages(), true), Configuration{LengthFieldPrefixed: true}, allMessages()},
+		{"raw", encodeMessages(allMessages(), false), Configuration{}, allMessages()},
+		{"gzip", gzipMessages(t, testMessages()), Configuration{LengthFieldPrefixed: true}, testMessages()},
+		{"filtered", encodeMessages(testMessages(), true), Configuration{LengthFieldPrefixed: true, MessageTypes: []byte{MESSAGE_ORDER_ADD}}, testMessages()[1:2]},
+		{"empty", nil, Configuration{LengthFieldPrefixed: true}, []ItchMessage{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ParseMapped(path, tt.config)
+			if err != nil {
+				t.Fatalf("ParseMapped() error = %v", err)
+			}
+
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("%v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestOpenMapped_Truncated(t *testing.T) {
+	data := encodeMessages(testMessages(), true)
+
+	path := filepath.Join(t.TempDir(), "01302020.NASDAQ_ITCH50")
+	if err := os.WriteFile(path, data[:len(data)-1], 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenMapped(path, Configuration{LengthFieldPrefixed: true})
+	if err != nil {
+		t.Fatalf("OpenMapped() error = %v", err)
+	}
+	defer d.Close()
+
+	var messages int
+	for {
+		_, err := d.Next()
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decoder.Next() error = %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+		messages++
+	}
+
+	if messages != 4 {
+		t.Errorf("got %d messages, want 4", messages)
+	}
+}
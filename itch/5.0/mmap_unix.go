@@ -0,0 +1,51 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapping is a read only memory mapped file
+type mapping struct {
+	data []byte
+}
+
+func mapFile(file *os.File) (*mapping, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	if size == 0 {
+		return &mapping{}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("file too large to map: %d bytes", size)
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mmap %s: %w", file.Name(), err)
+	}
+
+	return &mapping{data: data}, nil
+}
+
+func (m *mapping) Close() error {
+	if m.data == nil {
+		return nil
+	}
+
+	err := syscall.Munmap(m.data)
+	m.data = nil
+
+	return err
+}
@@ -13,62 +13,96 @@ import (
 )
 
 type MwcbLevel struct {
-	StockLocate    uint16
-	TrackingNumber uint16
-	Timestamp      time.Duration
-	LevelOne       udecimal.Decimal // Price (8)
-	LevelTwo       udecimal.Decimal // Price (8)
-	LevelThree     udecimal.Decimal // Price (8)
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	Timestamp      time.Duration    `json:"timestamp"`
+	LevelOne       udecimal.Decimal `json:"levelOne"`   // Price (8)
+	LevelTwo       udecimal.Decimal `json:"levelTwo"`   // Price (8)
+	LevelThree     udecimal.Decimal `json:"levelThree"` // Price (8)
 }
 
 func (m MwcbLevel) Type() uint8 {
 	return MESSAGE_MWCB_LEVEL
 }
 
+func (m MwcbLevel) Header() Header {
+	return Header{Type: MESSAGE_MWCB_LEVEL, StockLocate: m.StockLocate, TrackingNumber: m.TrackingNumber, Timestamp: m.Timestamp}
+}
+
 func (m MwcbLevel) Bytes() []byte {
-	data := make([]byte, mwcbLevelSize)
+	return m.appendBinary(make([]byte, 0, mwcbLevelSize))
+}
 
-	data[0] = MESSAGE_MWCB_LEVEL
-	binary.BigEndian.PutUint16(data[1:3], m.StockLocate)
+func (m MwcbLevel) MarshalBinary() ([]byte, error) {
+	return m.AppendBinary(make([]byte, 0, mwcbLevelSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(m.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], m.TrackingNumber)
+func (m MwcbLevel) AppendBinary(dst []byte) ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return dst, err
+	}
 
-	levelOne, _ := priceToBytes(m.LevelOne, 8)
-	levelTwo, _ := priceToBytes(m.LevelTwo, 8)
-	levelThree, _ := priceToBytes(m.LevelThree, 8)
+	return m.appendBinary(dst), nil
+}
 
-	copy(data[11:19], levelOne)
-	copy(data[19:27], levelTwo)
-	copy(data[27:], levelThree)
+func (m MwcbLevel) MarshalJSON() ([]byte, error) {
+	type message MwcbLevel
+	return marshalMessageJSON(MESSAGE_MWCB_LEVEL, message(m))
+}
 
-	return data
+func (m *MwcbLevel) UnmarshalJSON(data []byte) error {
+	type message MwcbLevel
+	return unmarshalMessageJSON(data, MESSAGE_MWCB_LEVEL, (*message)(m))
+}
+
+func (m MwcbLevel) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_MWCB_LEVEL, m.StockLocate, m.TrackingNumber, m.Timestamp)
+	dst = appendPrice(dst, m.LevelOne, 8)
+	dst = appendPrice(dst, m.LevelTwo, 8)
+	dst = appendPrice(dst, m.LevelThree, 8)
+
+	return dst
+}
+
+func (m MwcbLevel) validate() error {
+	v := validator{messageType: MESSAGE_MWCB_LEVEL}
+	v.timestamp("Timestamp", m.Timestamp)
+	v.price("LevelOne", m.LevelOne, 8)
+	v.price("LevelTwo", m.LevelTwo, 8)
+	v.price("LevelThree", m.LevelThree, 8)
+
+	return v.err
 }
 
 func ParseMwcbLevel(data []byte) (MwcbLevel, error) {
+	var m MwcbLevel
+	err := m.UnmarshalBinary(data)
+	return m, err
+}
+
+func (m *MwcbLevel) UnmarshalBinary(data []byte) error {
 	if len(data) != mwcbLevelSize {
-		return MwcbLevel{}, NewInvalidPacketSize(mwcbLevelSize, len(data))
+		return NewInvalidPacketSize(mwcbLevelSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	levelOne, _ := bytesToPrice(data[11:19], 8)
 	levelTwo, _ := bytesToPrice(data[19:27], 8)
 	levelThree, _ := bytesToPrice(data[27:], 8)
 
-	return MwcbLevel{
+	*m = MwcbLevel{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		LevelOne:       levelOne,
 		LevelTwo:       levelTwo,
 		LevelThree:     levelThree,
-	}, nil
+	}
+
+	return nil
 }
 
 func (l MwcbLevel) String() string {
@@ -10,49 +10,94 @@ import (
 	"time"
 )
 
+// BreachedLevel is the Market Wide Circuit Breaker level that has been breached
+type BreachedLevel uint8
+
+const (
+	BREACHED_LEVEL_1 BreachedLevel = '1'
+	BREACHED_LEVEL_2 BreachedLevel = '2'
+	BREACHED_LEVEL_3 BreachedLevel = '3'
+)
+
 type MwcbStatus struct {
-	Timestamp      time.Duration
-	StockLocate    uint16
-	TrackingNumber uint16
-	BreachedLevel  uint8
+	Timestamp      time.Duration `json:"timestamp"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	BreachedLevel  BreachedLevel `json:"breachedLevel"`
 }
 
 func (m MwcbStatus) Type() uint8 {
 	return MESSAGE_MWCB_STATUS
 }
 
+func (m MwcbStatus) Header() Header {
+	return Header{Type: MESSAGE_MWCB_STATUS, StockLocate: m.StockLocate, TrackingNumber: m.TrackingNumber, Timestamp: m.Timestamp}
+}
+
 func (m MwcbStatus) Bytes() []byte {
-	data := make([]byte, mwcbStatusSize)
+	return m.appendBinary(make([]byte, 0, mwcbStatusSize))
+}
+
+func (m MwcbStatus) MarshalBinary() ([]byte, error) {
+	return m.AppendBinary(make([]byte, 0, mwcbStatusSize))
+}
 
-	data[0] = MESSAGE_MWCB_STATUS
-	binary.BigEndian.PutUint16(data[1:3], m.StockLocate)
+func (m MwcbStatus) AppendBinary(dst []byte) ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return dst, err
+	}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(m.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], m.TrackingNumber)
+	return m.appendBinary(dst), nil
+}
+
+func (m MwcbStatus) MarshalJSON() ([]byte, error) {
+	type message MwcbStatus
+	return marshalMessageJSON(MESSAGE_MWCB_STATUS, message(m))
+}
+
+func (m *MwcbStatus) UnmarshalJSON(data []byte) error {
+	type message MwcbStatus
+	return unmarshalMessageJSON(data, MESSAGE_MWCB_STATUS, (*message)(m))
+}
+
+func (m MwcbStatus) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_MWCB_STATUS, m.StockLocate, m.TrackingNumber, m.Timestamp)
+	dst = append(dst, byte(m.BreachedLevel))
+
+	return dst
+}
 
-	data[11] = m.BreachedLevel
+func (m MwcbStatus) validate() error {
+	v := validator{messageType: MESSAGE_MWCB_STATUS}
+	v.timestamp("Timestamp", m.Timestamp)
+	v.enum("BreachedLevel", m.BreachedLevel.valid(), uint8(m.BreachedLevel))
 
-	return data
+	return v.err
 }
 
 func ParseMwcbStatus(data []byte) (MwcbStatus, error) {
+	var m MwcbStatus
+	err := m.UnmarshalBinary(data)
+	return m, err
+}
+
+func (m *MwcbStatus) UnmarshalBinary(data []byte) error {
 	if len(data) != mwcbStatusSize {
-		return MwcbStatus{}, NewInvalidPacketSize(mwcbStatusSize, len(data))
+		return NewInvalidPacketSize(mwcbStatusSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return MwcbStatus{
+	*m = MwcbStatus{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
-		BreachedLevel:  data[11],
-	}, nil
+		Timestamp:      timestamp,
+		BreachedLevel:  BreachedLevel(data[11]),
+	}
+
+	return nil
 }
 
 func (l MwcbStatus) String() string {
@@ -65,3 +110,33 @@ func (l MwcbStatus) String() string {
 		l.BreachedLevel,
 	)
 }
+
+func (b BreachedLevel) String() string {
+	switch b {
+	case BREACHED_LEVEL_1:
+		return "Level 1"
+	case BREACHED_LEVEL_2:
+		return "Level 2"
+	case BREACHED_LEVEL_3:
+		return "Level 3"
+	}
+
+	return "Unknown BreachedLevel"
+}
+
+func (b BreachedLevel) valid() bool {
+	switch b {
+	case BREACHED_LEVEL_1, BREACHED_LEVEL_2, BREACHED_LEVEL_3:
+		return true
+	}
+
+	return false
+}
+
+func (b BreachedLevel) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(b)), b.String())
+}
+
+func (b *BreachedLevel) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(b))
+}
@@ -7,7 +7,6 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/quagmt/udecimal"
@@ -22,85 +21,146 @@ const (
 	IMBALANCE_INSUFFICIENT ImbalanceDirection = 'O'
 )
 
+// VariationIndicator is how far the Near Price is from the Current Reference Price, as a percentage range
+type VariationIndicator uint8
+
+const (
+	VARIATION_UNDER_1_PERCENT VariationIndicator = 'L'
+	VARIATION_1_PERCENT       VariationIndicator = '1'
+	VARIATION_2_PERCENT       VariationIndicator = '2'
+	VARIATION_3_PERCENT       VariationIndicator = '3'
+	VARIATION_4_PERCENT       VariationIndicator = '4'
+	VARIATION_5_PERCENT       VariationIndicator = '5'
+	VARIATION_6_PERCENT       VariationIndicator = '6'
+	VARIATION_7_PERCENT       VariationIndicator = '7'
+	VARIATION_8_PERCENT       VariationIndicator = '8'
+	VARIATION_9_PERCENT       VariationIndicator = '9'
+	VARIATION_10_PERCENT      VariationIndicator = 'A'
+	VARIATION_20_PERCENT      VariationIndicator = 'B'
+	VARIATION_30_PERCENT      VariationIndicator = 'C'
+	VARIATION_NOT_CALCULATED  VariationIndicator = ' '
+)
+
 type Noii struct {
-	Stock              string
-	Timestamp          time.Duration
-	PairedShares       uint64
-	ImbalanceShares    uint64
-	FarPrice           udecimal.Decimal // Price (4)
-	NearPrice          udecimal.Decimal // Price (4)
-	CurrentPrice       udecimal.Decimal // Price (4)
-	StockLocate        uint16
-	TrackingNumber     uint16
-	ImbalanceDirection ImbalanceDirection
-	CrossType          CrossType
-	VariationIndicator uint8
+	Stock              string             `json:"stock"`
+	Timestamp          time.Duration      `json:"timestamp"`
+	PairedShares       uint64             `json:"pairedShares"`
+	ImbalanceShares    uint64             `json:"imbalanceShares"`
+	FarPrice           udecimal.Decimal   `json:"farPrice"`     // Price (4)
+	NearPrice          udecimal.Decimal   `json:"nearPrice"`    // Price (4)
+	CurrentPrice       udecimal.Decimal   `json:"currentPrice"` // Price (4)
+	StockLocate        uint16             `json:"stockLocate"`
+	TrackingNumber     uint16             `json:"trackingNumber"`
+	ImbalanceDirection ImbalanceDirection `json:"imbalanceDirection"`
+	CrossType          CrossType          `json:"crossType"`
+	VariationIndicator VariationIndicator `json:"variationIndicator"`
 }
 
 func (n Noii) Type() uint8 {
 	return MESSAGE_NOII
 }
 
+func (n Noii) Header() Header {
+	return Header{Type: MESSAGE_NOII, StockLocate: n.StockLocate, TrackingNumber: n.TrackingNumber, Timestamp: n.Timestamp}
+}
+
+func (n Noii) Symbol() Symbol {
+	return NewSymbol(n.Stock)
+}
+
 func (n Noii) Bytes() []byte {
-	data := make([]byte, noiiSize)
+	return n.appendBinary(make([]byte, 0, noiiSize))
+}
 
-	data[0] = MESSAGE_NOII
+func (n Noii) MarshalBinary() ([]byte, error) {
+	return n.AppendBinary(make([]byte, 0, noiiSize))
+}
 
-	binary.BigEndian.PutUint16(data[1:3], n.StockLocate)
+func (n Noii) AppendBinary(dst []byte) ([]byte, error) {
+	if err := n.validate(); err != nil {
+		return dst, err
+	}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(n.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], n.TrackingNumber)
+	return n.appendBinary(dst), nil
+}
 
-	binary.BigEndian.PutUint64(data[11:19], n.PairedShares)
-	binary.BigEndian.PutUint64(data[19:27], n.ImbalanceShares)
-	data[27] = byte(n.ImbalanceDirection)
+func (n Noii) MarshalJSON() ([]byte, error) {
+	type message Noii
+	return marshalMessageJSON(MESSAGE_NOII, message(n))
+}
 
-	copy(data[28:36], []byte(fmt.Sprintf("%-8s", n.Stock)))
+func (n *Noii) UnmarshalJSON(data []byte) error {
+	type message Noii
+	return unmarshalMessageJSON(data, MESSAGE_NOII, (*message)(n))
+}
 
-	farP, _ := priceToBytes(n.FarPrice, 4)
-	nearP, _ := priceToBytes(n.NearPrice, 4)
-	curP, _ := priceToBytes(n.CurrentPrice, 4)
+func (n Noii) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_NOII, n.StockLocate, n.TrackingNumber, n.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, n.PairedShares)
+	dst = binary.BigEndian.AppendUint64(dst, n.ImbalanceShares)
+	dst = append(dst, byte(n.ImbalanceDirection))
+	dst = appendAlpha(dst, n.Stock, 8)
+	dst = appendPrice(dst, n.FarPrice, 4)
+	dst = appendPrice(dst, n.NearPrice, 4)
+	dst = appendPrice(dst, n.CurrentPrice, 4)
+	dst = append(dst, byte(n.CrossType), byte(n.VariationIndicator))
 
-	copy(data[36:40], farP)
-	copy(data[40:44], nearP)
-	copy(data[44:48], curP)
+	return dst
+}
 
-	data[48] = byte(n.CrossType)
-	data[49] = n.VariationIndicator
+func (n Noii) validate() error {
+	v := validator{messageType: MESSAGE_NOII}
+	v.timestamp("Timestamp", n.Timestamp)
+	v.enum("ImbalanceDirection", n.ImbalanceDirection.valid(), uint8(n.ImbalanceDirection))
+	v.alpha("Stock", n.Stock, 8)
+	v.price("FarPrice", n.FarPrice, 4)
+	v.price("NearPrice", n.NearPrice, 4)
+	v.price("CurrentPrice", n.CurrentPrice, 4)
+	v.enum("CrossType", n.CrossType.valid(), uint8(n.CrossType))
+	v.enum("VariationIndicator", n.VariationIndicator.valid(), uint8(n.VariationIndicator))
 
-	return data
+	return v.err
 }
 
 func ParseNoii(data []byte) (Noii, error) {
+	var n Noii
+	err := n.UnmarshalBinary(data)
+	return n, err
+}
+
+func (n *Noii) UnmarshalBinary(data []byte) error {
+	return n.decode(data, nil)
+}
+
+func (n *Noii) decode(data []byte, symbols symbolTable) error {
 	if len(data) != noiiSize {
-		return Noii{}, NewInvalidPacketSize(noiiSize, len(data))
+		return NewInvalidPacketSize(noiiSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	farP, _ := bytesToPrice(data[36:40], 4)
 	nearP, _ := bytesToPrice(data[40:44], 4)
 	curP, _ := bytesToPrice(data[44:48], 4)
 
-	return Noii{
+	*n = Noii{
 		StockLocate:        locate,
 		TrackingNumber:     tracking,
-		Timestamp:          time.Duration(t),
+		Timestamp:          timestamp,
 		PairedShares:       binary.BigEndian.Uint64(data[11:19]),
 		ImbalanceShares:    binary.BigEndian.Uint64(data[19:27]),
 		ImbalanceDirection: ImbalanceDirection(data[27]),
-		Stock:              strings.TrimSpace(string(data[28:36])),
+		Stock:              symbols.get(data[28:36]),
 		FarPrice:           farP,
 		NearPrice:          nearP,
 		CurrentPrice:       curP,
 		CrossType:          CrossType(data[48]),
-		VariationIndicator: data[49],
-	}, nil
+		VariationIndicator: VariationIndicator(data[49]),
+	}
+
+	return nil
 }
 
 func (n Noii) String() string {
@@ -138,3 +198,59 @@ func (i ImbalanceDirection) String() string {
 
 	return "Unknown ImbalanceDirection"
 }
+
+func (i ImbalanceDirection) valid() bool {
+	switch i {
+	case IMBALANCE_BUY, IMBALANCE_SELL, IMBALANCE_NONE, IMBALANCE_INSUFFICIENT:
+		return true
+	}
+
+	return false
+}
+
+func (i ImbalanceDirection) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(i)), i.String())
+}
+
+func (i *ImbalanceDirection) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(i))
+}
+
+func (v VariationIndicator) String() string {
+	switch v {
+	case VARIATION_UNDER_1_PERCENT:
+		return "Less than 1%"
+	case VARIATION_1_PERCENT, VARIATION_2_PERCENT, VARIATION_3_PERCENT, VARIATION_4_PERCENT, VARIATION_5_PERCENT,
+		VARIATION_6_PERCENT, VARIATION_7_PERCENT, VARIATION_8_PERCENT, VARIATION_9_PERCENT:
+		return fmt.Sprintf("%c%% to %c.99%%", v, v)
+	case VARIATION_10_PERCENT:
+		return "10% to 19.99%"
+	case VARIATION_20_PERCENT:
+		return "20% to 29.99%"
+	case VARIATION_30_PERCENT:
+		return "30% or greater"
+	case VARIATION_NOT_CALCULATED:
+		return "Cannot be calculated"
+	}
+
+	return "Unknown VariationIndicator"
+}
+
+func (v VariationIndicator) valid() bool {
+	switch v {
+	case VARIATION_UNDER_1_PERCENT, VARIATION_1_PERCENT, VARIATION_2_PERCENT, VARIATION_3_PERCENT, VARIATION_4_PERCENT,
+		VARIATION_5_PERCENT, VARIATION_6_PERCENT, VARIATION_7_PERCENT, VARIATION_8_PERCENT, VARIATION_9_PERCENT,
+		VARIATION_10_PERCENT, VARIATION_20_PERCENT, VARIATION_30_PERCENT, VARIATION_NOT_CALCULATED:
+		return true
+	}
+
+	return false
+}
+
+func (v VariationIndicator) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(v)), v.String())
+}
+
+func (v *VariationIndicator) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(v))
+}
@@ -7,7 +7,6 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 )
 
@@ -24,55 +23,99 @@ const (
 )
 
 type OperationalHalt struct {
-	Stock          string
-	Timestamp      time.Duration
-	StockLocate    uint16
-	TrackingNumber uint16
-	MarketCode     MarketCode
-	HaltAction     HaltAction
+	Stock          string        `json:"stock"`
+	Timestamp      time.Duration `json:"timestamp"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	MarketCode     MarketCode    `json:"marketCode"`
+	HaltAction     HaltAction    `json:"haltAction"`
 }
 
 func (o OperationalHalt) Type() uint8 {
 	return MESSAGE_OPERATIONAL_HALT
 }
 
+func (o OperationalHalt) Header() Header {
+	return Header{Type: MESSAGE_OPERATIONAL_HALT, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
+func (o OperationalHalt) Symbol() Symbol {
+	return NewSymbol(o.Stock)
+}
+
 func (o OperationalHalt) Bytes() []byte {
-	data := make([]byte, operationalHaltSize)
+	return o.appendBinary(make([]byte, 0, operationalHaltSize))
+}
+
+func (o OperationalHalt) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, operationalHaltSize))
+}
+
+func (o OperationalHalt) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
+
+	return o.appendBinary(dst), nil
+}
 
-	data[0] = MESSAGE_OPERATIONAL_HALT
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OperationalHalt) MarshalJSON() ([]byte, error) {
+	type message OperationalHalt
+	return marshalMessageJSON(MESSAGE_OPERATIONAL_HALT, message(o))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o *OperationalHalt) UnmarshalJSON(data []byte) error {
+	type message OperationalHalt
+	return unmarshalMessageJSON(data, MESSAGE_OPERATIONAL_HALT, (*message)(o))
+}
 
-	copy(data[11:19], []byte(fmt.Sprintf("%-8s", o.Stock)))
+func (o OperationalHalt) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_OPERATIONAL_HALT, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = appendAlpha(dst, o.Stock, 8)
+	dst = append(dst, byte(o.MarketCode), byte(o.HaltAction))
+
+	return dst
+}
 
-	data[19] = byte(o.MarketCode)
-	data[20] = byte(o.HaltAction)
+func (o OperationalHalt) validate() error {
+	v := validator{messageType: MESSAGE_OPERATIONAL_HALT}
+	v.timestamp("Timestamp", o.Timestamp)
+	v.alpha("Stock", o.Stock, 8)
+	v.enum("MarketCode", o.MarketCode.valid(), uint8(o.MarketCode))
+	v.enum("HaltAction", o.HaltAction.valid(), uint8(o.HaltAction))
 
-	return data
+	return v.err
 }
 
 func ParseOperationalHalt(data []byte) (OperationalHalt, error) {
+	var o OperationalHalt
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OperationalHalt) UnmarshalBinary(data []byte) error {
+	return o.decode(data, nil)
+}
+
+func (o *OperationalHalt) decode(data []byte, symbols symbolTable) error {
 	if len(data) != operationalHaltSize {
-		return OperationalHalt{}, NewInvalidPacketSize(operationalHaltSize, len(data))
+		return NewInvalidPacketSize(operationalHaltSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return OperationalHalt{
+	*o = OperationalHalt{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
-		Stock:          strings.TrimSpace(string(data[11:19])),
+		Timestamp:      timestamp,
+		Stock:          symbols.get(data[11:19]),
 		MarketCode:     MarketCode(data[19]),
 		HaltAction:     HaltAction(data[20]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (h OperationalHalt) String() string {
@@ -101,6 +144,23 @@ func (m MarketCode) String() string {
 	return "Unknown MarketCode"
 }
 
+func (m MarketCode) valid() bool {
+	switch m {
+	case MARKET_CODE_NASDAQ, MARKET_CODE_BX, MARKET_CODE_PSX:
+		return true
+	}
+
+	return false
+}
+
+func (m MarketCode) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(m)), m.String())
+}
+
+func (m *MarketCode) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(m))
+}
+
 func (h HaltAction) String() string {
 	switch h {
 	case HALT_ACTION_HALT:
@@ -111,3 +171,20 @@ func (h HaltAction) String() string {
 
 	return "Unknown HaltAction"
 }
+
+func (h HaltAction) valid() bool {
+	switch h {
+	case HALT_ACTION_HALT, HALT_ACTION_LIFTED:
+		return true
+	}
+
+	return false
+}
+
+func (h HaltAction) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(h)), h.String())
+}
+
+func (h *HaltAction) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(h))
+}
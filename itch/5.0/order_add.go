@@ -7,7 +7,6 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/quagmt/udecimal"
@@ -21,133 +20,217 @@ const (
 )
 
 type OrderAdd struct {
-	Stock          string
-	Timestamp      time.Duration
-	Reference      uint64
-	Shares         uint32
-	Price          udecimal.Decimal // Price (4)
-	StockLocate    uint16
-	TrackingNumber uint16
-	OrderIndicator OrderIndicator
+	Stock          string           `json:"stock"`
+	Timestamp      time.Duration    `json:"timestamp"`
+	Reference      uint64           `json:"reference"`
+	Shares         uint32           `json:"shares"`
+	Price          udecimal.Decimal `json:"price"` // Price (4)
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	OrderIndicator OrderIndicator   `json:"orderIndicator"`
 }
 
 func (o OrderAdd) Type() uint8 {
 	return MESSAGE_ORDER_ADD
 }
 
+func (o OrderAdd) Header() Header {
+	return Header{Type: MESSAGE_ORDER_ADD, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
+func (o OrderAdd) Symbol() Symbol {
+	return NewSymbol(o.Stock)
+}
+
 func (o OrderAdd) Bytes() []byte {
-	data := make([]byte, orderAddSize)
+	return o.appendBinary(make([]byte, 0, orderAddSize))
+}
 
-	data[0] = MESSAGE_ORDER_ADD
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OrderAdd) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, orderAddSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o OrderAdd) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
 
-	binary.BigEndian.PutUint64(data[11:19], o.Reference)
-	data[19] = byte(o.OrderIndicator)
+	return o.appendBinary(dst), nil
+}
 
-	binary.BigEndian.PutUint32(data[20:24], o.Shares)
+func (o OrderAdd) MarshalJSON() ([]byte, error) {
+	type message OrderAdd
+	return marshalMessageJSON(MESSAGE_ORDER_ADD, message(o))
+}
 
-	copy(data[24:32], []byte(fmt.Sprintf("%-8s", o.Stock)))
+func (o *OrderAdd) UnmarshalJSON(data []byte) error {
+	type message OrderAdd
+	return unmarshalMessageJSON(data, MESSAGE_ORDER_ADD, (*message)(o))
+}
 
-	price, _ := priceToBytes(o.Price, 4)
-	copy(data[32:36], price)
+func (o OrderAdd) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_ORDER_ADD, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, o.Reference)
+	dst = append(dst, byte(o.OrderIndicator))
+	dst = binary.BigEndian.AppendUint32(dst, o.Shares)
+	dst = appendAlpha(dst, o.Stock, 8)
+	dst = appendPrice(dst, o.Price, 4)
 
-	return data
+	return dst
+}
+
+func (o OrderAdd) validate() error {
+	v := validator{messageType: MESSAGE_ORDER_ADD}
+	v.timestamp("Timestamp", o.Timestamp)
+	v.enum("OrderIndicator", o.OrderIndicator.valid(), uint8(o.OrderIndicator))
+	v.alpha("Stock", o.Stock, 8)
+	v.price("Price", o.Price, 4)
+
+	return v.err
 }
 
 type OrderAddAttributed struct {
-	Stock          string
-	Attribution    string
-	Timestamp      time.Duration
-	Reference      uint64
-	Shares         uint32
-	Price          udecimal.Decimal // Price (4)
-	StockLocate    uint16
-	TrackingNumber uint16
-	OrderIndicator OrderIndicator
+	Stock          string           `json:"stock"`
+	Attribution    string           `json:"attribution"`
+	Timestamp      time.Duration    `json:"timestamp"`
+	Reference      uint64           `json:"reference"`
+	Shares         uint32           `json:"shares"`
+	Price          udecimal.Decimal `json:"price"` // Price (4)
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	OrderIndicator OrderIndicator   `json:"orderIndicator"`
 }
 
 func (o OrderAddAttributed) Type() uint8 {
 	return MESSAGE_ORDER_ADD_ATTRIBUTED
 }
 
+func (o OrderAddAttributed) Header() Header {
+	return Header{Type: MESSAGE_ORDER_ADD_ATTRIBUTED, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
+func (o OrderAddAttributed) Symbol() Symbol {
+	return NewSymbol(o.Stock)
+}
+
 func (o OrderAddAttributed) Bytes() []byte {
-	data := make([]byte, orderAddAttrSize)
+	return o.appendBinary(make([]byte, 0, orderAddAttrSize))
+}
 
-	data[0] = MESSAGE_ORDER_ADD_ATTRIBUTED
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OrderAddAttributed) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, orderAddAttrSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o OrderAddAttributed) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
 
-	binary.BigEndian.PutUint64(data[11:19], o.Reference)
-	data[19] = byte(o.OrderIndicator)
+	return o.appendBinary(dst), nil
+}
+
+func (o OrderAddAttributed) MarshalJSON() ([]byte, error) {
+	type message OrderAddAttributed
+	return marshalMessageJSON(MESSAGE_ORDER_ADD_ATTRIBUTED, message(o))
+}
 
-	binary.BigEndian.PutUint32(data[20:24], o.Shares)
+func (o *OrderAddAttributed) UnmarshalJSON(data []byte) error {
+	type message OrderAddAttributed
+	return unmarshalMessageJSON(data, MESSAGE_ORDER_ADD_ATTRIBUTED, (*message)(o))
+}
 
-	copy(data[24:32], []byte(fmt.Sprintf("%-8s", o.Stock)))
+func (o OrderAddAttributed) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_ORDER_ADD_ATTRIBUTED, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, o.Reference)
+	dst = append(dst, byte(o.OrderIndicator))
+	dst = binary.BigEndian.AppendUint32(dst, o.Shares)
+	dst = appendAlpha(dst, o.Stock, 8)
+	dst = appendPrice(dst, o.Price, 4)
+	dst = appendAlpha(dst, o.Attribution, 4)
 
-	price, _ := priceToBytes(o.Price, 4)
-	copy(data[32:36], price)
+	return dst
+}
 
-	copy(data[36:], []byte(fmt.Sprintf("%-4s", o.Attribution)))
+func (o OrderAddAttributed) validate() error {
+	v := validator{messageType: MESSAGE_ORDER_ADD_ATTRIBUTED}
+	v.timestamp("Timestamp", o.Timestamp)
+	v.enum("OrderIndicator", o.OrderIndicator.valid(), uint8(o.OrderIndicator))
+	v.alpha("Stock", o.Stock, 8)
+	v.price("Price", o.Price, 4)
+	v.alpha("Attribution", o.Attribution, 4)
 
-	return data
+	return v.err
 }
 
 func ParseOrderAdd(data []byte) (OrderAdd, error) {
+	var o OrderAdd
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OrderAdd) UnmarshalBinary(data []byte) error {
+	return o.decode(data, nil)
+}
+
+func (o *OrderAdd) decode(data []byte, symbols symbolTable) error {
 	if len(data) != orderAddSize {
-		return OrderAdd{}, NewInvalidPacketSize(orderAddSize, len(data))
+		return NewInvalidPacketSize(orderAddSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	price, _ := bytesToPrice(data[32:], 4)
 
-	return OrderAdd{
+	*o = OrderAdd{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Reference:      binary.BigEndian.Uint64(data[11:19]),
 		OrderIndicator: OrderIndicator(data[19]),
 		Shares:         binary.BigEndian.Uint32(data[20:24]),
-		Stock:          strings.TrimSpace(string(data[24:32])),
+		Stock:          symbols.get(data[24:32]),
 		Price:          price,
-	}, nil
+	}
+
+	return nil
 }
 
 func ParseOrderAddAttributed(data []byte) (OrderAddAttributed, error) {
+	var o OrderAddAttributed
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OrderAddAttributed) UnmarshalBinary(data []byte) error {
+	return o.decode(data, nil)
+}
+
+func (o *OrderAddAttributed) decode(data []byte, symbols symbolTable) error {
 	if len(data) != orderAddAttrSize {
-		return OrderAddAttributed{}, NewInvalidPacketSize(orderAddAttrSize, len(data))
+		return NewInvalidPacketSize(orderAddAttrSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	price, _ := bytesToPrice(data[32:36], 4)
 
-	return OrderAddAttributed{
+	*o = OrderAddAttributed{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Reference:      binary.BigEndian.Uint64(data[11:19]),
 		OrderIndicator: OrderIndicator(data[19]),
 		Shares:         binary.BigEndian.Uint32(data[20:24]),
-		Stock:          strings.TrimSpace(string(data[24:32])),
+		Stock:          symbols.get(data[24:32]),
 		Price:          price,
-		Attribution:    strings.TrimSpace(string(data[36:])),
-	}, nil
+		Attribution:    symbols.get(data[36:40]),
+	}
+
+	return nil
 }
 
 func (a OrderAdd) String() string {
@@ -193,3 +276,20 @@ func (o OrderIndicator) String() string {
 
 	return "Unknown OrderIndicator"
 }
+
+func (o OrderIndicator) valid() bool {
+	switch o {
+	case ORDER_INDICATOR_BUY, ORDER_INDICATOR_SELL:
+		return true
+	}
+
+	return false
+}
+
+func (o OrderIndicator) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(o)), o.String())
+}
+
+func (o *OrderIndicator) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(o))
+}
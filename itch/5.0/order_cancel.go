@@ -11,51 +11,86 @@ import (
 )
 
 type OrderCancel struct {
-	Timestamp      time.Duration
-	Reference      uint64
-	Shares         uint32
-	StockLocate    uint16
-	TrackingNumber uint16
+	Timestamp      time.Duration `json:"timestamp"`
+	Reference      uint64        `json:"reference"`
+	Shares         uint32        `json:"shares"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
 }
 
 func (o OrderCancel) Type() uint8 {
 	return MESSAGE_ORDER_CANCEL
 }
 
+func (o OrderCancel) Header() Header {
+	return Header{Type: MESSAGE_ORDER_CANCEL, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
 func (o OrderCancel) Bytes() []byte {
-	data := make([]byte, orderCancelSize)
+	return o.appendBinary(make([]byte, 0, orderCancelSize))
+}
+
+func (o OrderCancel) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, orderCancelSize))
+}
+
+func (o OrderCancel) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
+
+	return o.appendBinary(dst), nil
+}
 
-	data[0] = MESSAGE_ORDER_CANCEL
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OrderCancel) MarshalJSON() ([]byte, error) {
+	type message OrderCancel
+	return marshalMessageJSON(MESSAGE_ORDER_CANCEL, message(o))
+}
+
+func (o *OrderCancel) UnmarshalJSON(data []byte) error {
+	type message OrderCancel
+	return unmarshalMessageJSON(data, MESSAGE_ORDER_CANCEL, (*message)(o))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o OrderCancel) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_ORDER_CANCEL, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, o.Reference)
+	dst = binary.BigEndian.AppendUint32(dst, o.Shares)
+
+	return dst
+}
 
-	binary.BigEndian.PutUint64(data[11:19], o.Reference)
-	binary.BigEndian.PutUint32(data[19:], o.Shares)
+func (o OrderCancel) validate() error {
+	v := validator{messageType: MESSAGE_ORDER_CANCEL}
+	v.timestamp("Timestamp", o.Timestamp)
 
-	return data
+	return v.err
 }
 
 func ParseOrderCancel(data []byte) (OrderCancel, error) {
+	var o OrderCancel
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OrderCancel) UnmarshalBinary(data []byte) error {
 	if len(data) != orderCancelSize {
-		return OrderCancel{}, NewInvalidPacketSize(orderCancelSize, len(data))
+		return NewInvalidPacketSize(orderCancelSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return OrderCancel{
+	*o = OrderCancel{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Reference:      binary.BigEndian.Uint64(data[11:19]),
 		Shares:         binary.BigEndian.Uint32(data[19:23]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (o OrderCancel) String() string {
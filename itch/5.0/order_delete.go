@@ -11,48 +11,83 @@ import (
 )
 
 type OrderDelete struct {
-	StockLocate    uint16
-	TrackingNumber uint16
-	Timestamp      time.Duration
-	Reference      uint64
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	Timestamp      time.Duration `json:"timestamp"`
+	Reference      uint64        `json:"reference"`
 }
 
 func (o OrderDelete) Type() uint8 {
 	return MESSAGE_ORDER_DELETE
 }
 
+func (o OrderDelete) Header() Header {
+	return Header{Type: MESSAGE_ORDER_DELETE, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
 func (o OrderDelete) Bytes() []byte {
-	data := make([]byte, orderDeleteSize)
+	return o.appendBinary(make([]byte, 0, orderDeleteSize))
+}
+
+func (o OrderDelete) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, orderDeleteSize))
+}
+
+func (o OrderDelete) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
+
+	return o.appendBinary(dst), nil
+}
 
-	data[0] = MESSAGE_ORDER_DELETE
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OrderDelete) MarshalJSON() ([]byte, error) {
+	type message OrderDelete
+	return marshalMessageJSON(MESSAGE_ORDER_DELETE, message(o))
+}
+
+func (o *OrderDelete) UnmarshalJSON(data []byte) error {
+	type message OrderDelete
+	return unmarshalMessageJSON(data, MESSAGE_ORDER_DELETE, (*message)(o))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o OrderDelete) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_ORDER_DELETE, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, o.Reference)
+
+	return dst
+}
 
-	binary.BigEndian.PutUint64(data[11:], o.Reference)
+func (o OrderDelete) validate() error {
+	v := validator{messageType: MESSAGE_ORDER_DELETE}
+	v.timestamp("Timestamp", o.Timestamp)
 
-	return data
+	return v.err
 }
 
 func ParseOrderDelete(data []byte) (OrderDelete, error) {
+	var o OrderDelete
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OrderDelete) UnmarshalBinary(data []byte) error {
 	if len(data) != orderDeleteSize {
-		return OrderDelete{}, NewInvalidPacketSize(orderDeleteSize, len(data))
+		return NewInvalidPacketSize(orderDeleteSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return OrderDelete{
+	*o = OrderDelete{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Reference:      binary.BigEndian.Uint64(data[11:19]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (o OrderDelete) String() string {
@@ -13,107 +13,169 @@ import (
 )
 
 type OrderExecuted struct {
-	Timestamp      time.Duration
-	Reference      uint64
-	MatchNumber    uint64
-	Shares         uint32
-	StockLocate    uint16
-	TrackingNumber uint16
+	Timestamp      time.Duration `json:"timestamp"`
+	Reference      uint64        `json:"reference"`
+	MatchNumber    uint64        `json:"matchNumber"`
+	Shares         uint32        `json:"shares"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
 }
 
 func (o OrderExecuted) Type() uint8 {
 	return MESSAGE_ORDER_EXECUTED
 }
 
+func (o OrderExecuted) Header() Header {
+	return Header{Type: MESSAGE_ORDER_EXECUTED, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
 func (o OrderExecuted) Bytes() []byte {
-	data := make([]byte, orderExecutedSize)
+	return o.appendBinary(make([]byte, 0, orderExecutedSize))
+}
+
+func (o OrderExecuted) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, orderExecutedSize))
+}
+
+func (o OrderExecuted) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
+
+	return o.appendBinary(dst), nil
+}
 
-	data[0] = MESSAGE_ORDER_EXECUTED
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OrderExecuted) MarshalJSON() ([]byte, error) {
+	type message OrderExecuted
+	return marshalMessageJSON(MESSAGE_ORDER_EXECUTED, message(o))
+}
+
+func (o *OrderExecuted) UnmarshalJSON(data []byte) error {
+	type message OrderExecuted
+	return unmarshalMessageJSON(data, MESSAGE_ORDER_EXECUTED, (*message)(o))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o OrderExecuted) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_ORDER_EXECUTED, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, o.Reference)
+	dst = binary.BigEndian.AppendUint32(dst, o.Shares)
+	dst = binary.BigEndian.AppendUint64(dst, o.MatchNumber)
+
+	return dst
+}
 
-	binary.BigEndian.PutUint64(data[11:19], o.Reference)
-	binary.BigEndian.PutUint32(data[19:23], o.Shares)
-	binary.BigEndian.PutUint64(data[23:], o.MatchNumber)
+func (o OrderExecuted) validate() error {
+	v := validator{messageType: MESSAGE_ORDER_EXECUTED}
+	v.timestamp("Timestamp", o.Timestamp)
 
-	return data
+	return v.err
 }
 
 type OrderExecutedPrice struct {
-	Timestamp      time.Duration
-	Reference      uint64
-	MatchNumber    uint64
-	Shares         uint32
-	ExecutionPrice udecimal.Decimal // Price (4)
-	StockLocate    uint16
-	TrackingNumber uint16
-	Printable      bool
+	Timestamp      time.Duration    `json:"timestamp"`
+	Reference      uint64           `json:"reference"`
+	MatchNumber    uint64           `json:"matchNumber"`
+	Shares         uint32           `json:"shares"`
+	ExecutionPrice udecimal.Decimal `json:"executionPrice"` // Price (4)
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	Printable      bool             `json:"printable"`
 }
 
 func (o OrderExecutedPrice) Type() uint8 {
 	return MESSAGE_ORDER_EXECUTED_PRICE
 }
 
+func (o OrderExecutedPrice) Header() Header {
+	return Header{Type: MESSAGE_ORDER_EXECUTED_PRICE, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
 func (o OrderExecutedPrice) Bytes() []byte {
-	data := make([]byte, orderExecutedPriceSize)
+	return o.appendBinary(make([]byte, 0, orderExecutedPriceSize))
+}
 
-	data[0] = MESSAGE_ORDER_EXECUTED_PRICE
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OrderExecutedPrice) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, orderExecutedPriceSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o OrderExecutedPrice) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
 
-	binary.BigEndian.PutUint64(data[11:19], o.Reference)
-	binary.BigEndian.PutUint32(data[19:23], o.Shares)
-	binary.BigEndian.PutUint64(data[23:31], o.MatchNumber)
+	return o.appendBinary(dst), nil
+}
 
-	if o.Printable {
-		data[31] = 'Y'
-	} else {
-		data[31] = 'N'
-	}
+func (o OrderExecutedPrice) MarshalJSON() ([]byte, error) {
+	type message OrderExecutedPrice
+	return marshalMessageJSON(MESSAGE_ORDER_EXECUTED_PRICE, message(o))
+}
+
+func (o *OrderExecutedPrice) UnmarshalJSON(data []byte) error {
+	type message OrderExecutedPrice
+	return unmarshalMessageJSON(data, MESSAGE_ORDER_EXECUTED_PRICE, (*message)(o))
+}
+
+func (o OrderExecutedPrice) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_ORDER_EXECUTED_PRICE, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, o.Reference)
+	dst = binary.BigEndian.AppendUint32(dst, o.Shares)
+	dst = binary.BigEndian.AppendUint64(dst, o.MatchNumber)
+	dst = appendBool(dst, o.Printable)
+	dst = appendPrice(dst, o.ExecutionPrice, 4)
+
+	return dst
+}
 
-	price, _ := priceToBytes(o.ExecutionPrice, 4)
-	copy(data[32:], price)
+func (o OrderExecutedPrice) validate() error {
+	v := validator{messageType: MESSAGE_ORDER_EXECUTED_PRICE}
+	v.timestamp("Timestamp", o.Timestamp)
+	v.price("ExecutionPrice", o.ExecutionPrice, 4)
 
-	return data
+	return v.err
 }
 
 func ParseOrderExecuted(data []byte) (OrderExecuted, error) {
+	var o OrderExecuted
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OrderExecuted) UnmarshalBinary(data []byte) error {
 	if len(data) != orderExecutedSize {
-		return OrderExecuted{}, NewInvalidPacketSize(orderExecutedSize, len(data))
+		return NewInvalidPacketSize(orderExecutedSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return OrderExecuted{
+	*o = OrderExecuted{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Reference:      binary.BigEndian.Uint64(data[11:19]),
 		Shares:         binary.BigEndian.Uint32(data[19:23]),
 		MatchNumber:    binary.BigEndian.Uint64(data[23:31]),
-	}, nil
+	}
+
+	return nil
 }
 
 func ParseOrderExecutedPrice(data []byte) (OrderExecutedPrice, error) {
+	var o OrderExecutedPrice
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OrderExecutedPrice) UnmarshalBinary(data []byte) error {
 	if len(data) != orderExecutedPriceSize {
-		return OrderExecutedPrice{}, NewInvalidPacketSize(orderExecutedPriceSize, len(data))
+		return NewInvalidPacketSize(orderExecutedPriceSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	printable := false
 	if data[31] == 'Y' {
@@ -122,16 +184,18 @@ func ParseOrderExecutedPrice(data []byte) (OrderExecutedPrice, error) {
 
 	price, _ := bytesToPrice(data[32:], 4)
 
-	return OrderExecutedPrice{
+	*o = OrderExecutedPrice{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Reference:      binary.BigEndian.Uint64(data[11:19]),
 		Shares:         binary.BigEndian.Uint32(data[19:23]),
 		MatchNumber:    binary.BigEndian.Uint64(data[23:31]),
 		Printable:      printable,
 		ExecutionPrice: price,
-	}, nil
+	}
+
+	return nil
 }
 
 func (o OrderExecuted) String() string {
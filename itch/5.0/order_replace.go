@@ -13,61 +13,95 @@ import (
 )
 
 type OrderReplace struct {
-	StockLocate       uint16
-	TrackingNumber    uint16
-	Timestamp         time.Duration
-	OriginalReference uint64
-	NewReference      uint64
-	Shares            uint32
-	Price             udecimal.Decimal // Price (4)
+	StockLocate       uint16           `json:"stockLocate"`
+	TrackingNumber    uint16           `json:"trackingNumber"`
+	Timestamp         time.Duration    `json:"timestamp"`
+	OriginalReference uint64           `json:"originalReference"`
+	NewReference      uint64           `json:"newReference"`
+	Shares            uint32           `json:"shares"`
+	Price             udecimal.Decimal `json:"price"` // Price (4)
 }
 
 func (o OrderReplace) Type() uint8 {
 	return MESSAGE_ORDER_REPLACE
 }
 
+func (o OrderReplace) Header() Header {
+	return Header{Type: MESSAGE_ORDER_REPLACE, StockLocate: o.StockLocate, TrackingNumber: o.TrackingNumber, Timestamp: o.Timestamp}
+}
+
 func (o OrderReplace) Bytes() []byte {
-	data := make([]byte, orderReplaceSize)
+	return o.appendBinary(make([]byte, 0, orderReplaceSize))
+}
 
-	data[0] = MESSAGE_ORDER_REPLACE
-	binary.BigEndian.PutUint16(data[1:3], o.StockLocate)
+func (o OrderReplace) MarshalBinary() ([]byte, error) {
+	return o.AppendBinary(make([]byte, 0, orderReplaceSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(o.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], o.TrackingNumber)
+func (o OrderReplace) AppendBinary(dst []byte) ([]byte, error) {
+	if err := o.validate(); err != nil {
+		return dst, err
+	}
 
-	binary.BigEndian.PutUint64(data[11:19], o.OriginalReference)
-	binary.BigEndian.PutUint64(data[19:27], o.NewReference)
-	binary.BigEndian.PutUint32(data[27:31], o.Shares)
+	return o.appendBinary(dst), nil
+}
 
-	price, _ := priceToBytes(o.Price, 4)
-	copy(data[31:], price)
+func (o OrderReplace) MarshalJSON() ([]byte, error) {
+	type message OrderReplace
+	return marshalMessageJSON(MESSAGE_ORDER_REPLACE, message(o))
+}
 
-	return data
+func (o *OrderReplace) UnmarshalJSON(data []byte) error {
+	type message OrderReplace
+	return unmarshalMessageJSON(data, MESSAGE_ORDER_REPLACE, (*message)(o))
+}
+
+func (o OrderReplace) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_ORDER_REPLACE, o.StockLocate, o.TrackingNumber, o.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, o.OriginalReference)
+	dst = binary.BigEndian.AppendUint64(dst, o.NewReference)
+	dst = binary.BigEndian.AppendUint32(dst, o.Shares)
+	dst = appendPrice(dst, o.Price, 4)
+
+	return dst
+}
+
+func (o OrderReplace) validate() error {
+	v := validator{messageType: MESSAGE_ORDER_REPLACE}
+	v.timestamp("Timestamp", o.Timestamp)
+	v.price("Price", o.Price, 4)
+
+	return v.err
 }
 
 func ParseOrderReplace(data []byte) (OrderReplace, error) {
+	var o OrderReplace
+	err := o.UnmarshalBinary(data)
+	return o, err
+}
+
+func (o *OrderReplace) UnmarshalBinary(data []byte) error {
 	if len(data) != orderReplaceSize {
-		return OrderReplace{}, NewInvalidPacketSize(orderReplaceSize, len(data))
+		return NewInvalidPacketSize(orderReplaceSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	price, _ := bytesToPrice(data[31:], 4)
 
-	return OrderReplace{
+	*o = OrderReplace{
 		StockLocate:       locate,
 		TrackingNumber:    tracking,
-		Timestamp:         time.Duration(t),
+		Timestamp:         timestamp,
 		OriginalReference: binary.BigEndian.Uint64(data[11:19]),
 		NewReference:      binary.BigEndian.Uint64(data[19:27]),
 		Shares:            binary.BigEndian.Uint32(data[27:31]),
 		Price:             price,
-	}, nil
+	}
+
+	return nil
 }
 
 func (o OrderReplace) String() string {
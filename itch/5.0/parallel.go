@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// parallelChunkSize is the amount of message data handed to a worker at a time
+const parallelChunkSize = 1 << 20
+
+// chunk is a run of consecutive messages decoded by a single worker
+type chunk struct {
+	seq int
+
+	// data holds the messages back to back without length prefixes
+	data   []byte
+	frames []frame
+
+	messages []ItchMessage
+	err      error
+}
+
+// frame locates a message in a chunk and in the original input
+type frame struct {
+	end    int
+	offset int64
+	index  int
+}
+
+var chunkPool = sync.Pool{
+	New: func() any {
+		return &chunk{data: make([]byte, 0, parallelChunkSize+1<<16)}
+	},
+}
+
+// fill copies messages from the Decoder until the chunk is full. The error is io.EOF at the end of the input
+func (c *chunk) fill(d *Decoder) error {
+	for len(c.data) < parallelChunkSize {
+		data, err := d.readFrame()
+		if err != nil {
+			// Skipped data is reported with the chunk unless it stopped the Decoder
+			if d.err == nil {
+				c.err = errors.Join(c.err, err)
+				continue
+			}
+			return err
+		}
+
+		c.data = append(c.data, data...)
+		c.frames = append(c.frames, frame{end: len(c.data), offset: d.frameOffset, index: d.frameIndex})
+	}
+
+	return nil
+}
+
+// parse parses every message in the chunk. With ERROR_POLICY_STOP parsing stops at the first error
+func (c *chunk) parse(symbols symbolTable, policy ErrorPolicy) {
+	start := 0
+
+	for _, f := range c.frames {
+		data := c.data[start:f.end]
+		start = f.end
+
+		m, err := parseData(data, symbols)
+		if err != nil {
+			switch policy {
+			case ERROR_POLICY_SKIP:
+				continue
+			case ERROR_POLICY_STOP:
+				c.err = errors.Join(c.err, NewDecodeError(f.offset, f.index, data[0], err))
+				return
+			}
+
+			c.err = errors.Join(c.err, NewDecodeError(f.offset, f.index, data[0], err))
+			continue
+		}
+
+		c.messages = append(c.messages, m)
+	}
+}
+
+func (c *chunk) release() {
+	clear(c.messages)
+
+	c.data = c.data[:0]
+	c.frames = c.frames[:0]
+	c.messages = c.messages[:0]
+	c.err = nil
+
+	chunkPool.Put(c)
+}
+
+// ParseFileParallel parses ITCH messages from a file, which may be gzip compressed, using multiple goroutines.
+// See DecodeParallel.
+func ParseFileParallel(path string, config Configuration) ([]ItchMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	messages := []ItchMessage{}
+
+	err = decodeParallel(file, config, func(c *chunk) {
+		messages = append(messages, c.messages...)
+	})
+
+	return messages, err
+}
+
+// DecodeParallel decodes every message from reader using Configuration.Workers goroutines and passes it to handler.
+//
+// A single goroutine splits the input into chunks at message boundaries, which are then parsed concurrently.
+// The handler is only ever called from the calling goroutine, in the original message order unless
+// Configuration.Unordered is set. Errors parsing a message are handled according to Configuration.ErrorPolicy,
+// by default they are joined together and returned after parsing all messages.
+func DecodeParallel(reader io.Reader, config Configuration, handler Handler) error {
+	return decodeParallel(reader, config, func(c *chunk) {
+		for _, m := range c.messages {
+			if m != nil {
+				_ = Dispatch(m, handler)
+			}
+		}
+	})
+}
+
+// decodeParallel parses the input in chunks across the workers and calls deliver with each parsed chunk from
+// the calling goroutine. deliver must not retain the chunk
+func decodeParallel(reader io.Reader, config Configuration, deliver func(*chunk)) error {
+	workers := config.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	d := NewDecoder(reader, config)
+	defer d.Close()
+
+	jobs := make(chan *chunk)
+	results := make(chan *chunk)
+
+	// Limits how many chunks are held in memory while waiting for a slower worker
+	inflight := make(chan struct{}, 2*workers)
+
+	// Closed to stop reading when a message fails to parse with ERROR_POLICY_STOP
+	stop := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			symbols := newSymbolTable()
+			for c := range jobs {
+				c.parse(symbols, config.ErrorPolicy)
+				results <- c
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+
+		for seq := 0; ; {
+			select {
+			case inflight <- struct{}{}:
+			case <-stop:
+				return
+			}
+
+			c := chunkPool.Get().(*chunk)
+			c.seq = seq
+
+			readErr = c.fill(d)
+
+			if len(c.frames) > 0 {
+				jobs <- c
+				seq++
+			} else {
+				c.release()
+				<-inflight
+			}
+
+			if readErr != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	allErrs := error(nil)
+	stopped := false
+
+	emit := func(c *chunk) {
+		if !stopped {
+			deliver(c)
+			allErrs = errors.Join(allErrs, c.err)
+
+			if c.err != nil && config.ErrorPolicy == ERROR_POLICY_STOP {
+				stopped = true
+				close(stop)
+			}
+		}
+
+		c.release()
+		<-inflight
+	}
+
+	pending := make(map[int]*chunk)
+	next := 0
+
+	for c := range results {
+		if config.Unordered {
+			emit(c)
+			continue
+		}
+
+		pending[c.seq] = c
+
+		for {
+			c, ok := pending[next]
+			if !ok {
+				break
+			}
+
+			delete(pending, next)
+			emit(c)
+			next++
+		}
+	}
+
+	if readErr != io.EOF && !stopped {
+		allErrs = errors.Join(allErrs, readErr)
+	}
+
+	return allErrs
+}
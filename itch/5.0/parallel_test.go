@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quagmt/udecimal"
+)
+
+// parallelMessages returns enough messages to be split into several chunks
+func parallelMessages() []ItchMessage {
+	messages := allMessages()
+
+	for i := range 4 * parallelChunkSize / orderAddSize {
+		messages = append(messages, OrderAdd{
+			Stock:          "AAPL",
+			Timestamp:      time.Duration(i),
+			Reference:      uint64(i),
+			Shares:         100,
+			Price:          udecimal.MustParse("300.01"),
+			StockLocate:    13,
+			OrderIndicator: ORDER_INDICATOR_BUY,
+		})
+	}
+
+	return append(messages, SystemEvent{Timestamp: 20 * time.Hour, EventCode: EVENT_END_MESSAGES})
+}
+
+func writeTestFile(t *testing.T, messages []ItchMessage) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "01302020.NASDAQ_ITCH50")
+	if err := os.WriteFile(path, encodeMessages(messages, true), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestParseFileParallel(t *testing.T) {
+	want := parallelMessages()
+	path := writeTestFile(t, want)
+
+	got, err := ParseFileParallel(path, Configuration{LengthFieldPrefixed: true, Workers: 4})
+	if err != nil {
+		t.Fatalf("ParseFileParallel() error = %v", err)
+	}
+
+	if !cmp.Equal(got, want) {
+		t.Errorf("ParseFileParallel() returned %d messages not matching the %d written", len(got), len(want))
+	}
+}
+
+func TestParseFileParallel_Unordered(t *testing.T) {
+	want := parallelMessages()
+	path := writeTestFile(t, want)
+
+	got, err := ParseFileParallel(path, Configuration{LengthFieldPrefixed: true, Workers: 4, Unordered: true})
+	if err != nil {
+		t.Fatalf("ParseFileParallel() error = %v", err)
+	}
+
+	key := func(m ItchMessage) string {
+		return string(m.Bytes())
+	}
+	sorted := func(messages []ItchMessage) []string {
+		keys := make([]string, 0, len(messages))
+		for _, m := range messages {
+			keys = append(keys, key(m))
+		}
+		slices.Sort(keys)
+		return keys
+	}
+
+	if !slices.Equal(sorted(got), sorted(want)) {
+		t.Errorf("ParseFileParallel() returned %d messages not matching the %d written", len(got), len(want))
+	}
+}
+
+func TestParseFileParallel_Configuration(t *testing.T) {
+	path := writeTestFile(t, parallelMessages())
+
+	config := Configuration{LengthFieldPrefixed: true, MessageTypes: []byte{MESSAGE_SYSTEM_EVENT, MESSAGE_STOCK_DIRECTORY}, FeedState: NewFeedState()}
+
+	got, err := ParseFileParallel(path, config)
+	if err != nil {
+		t.Fatalf("ParseFileParallel() error = %v", err)
+	}
+
+	if len(got) != 3 || got[0].Type() != MESSAGE_SYSTEM_EVENT || got[1].Type() != MESSAGE_STOCK_DIRECTORY || got[2].Type() != MESSAGE_SYSTEM_EVENT {
+		t.Errorf("ParseFileParallel() = %v, want System Event, Stock Directory, System Event", got)
+	}
+
+	if _, ok := config.FeedState.Locate("AAPL"); !ok {
+		t.Errorf("FeedState.Locate() not found")
+	}
+
+	config = Configuration{LengthFieldPrefixed: true, MaxMessages: 10}
+
+	got, err = ParseFileParallel(path, config)
+	if err != nil {
+		t.Fatalf("ParseFileParallel() error = %v", err)
+	}
+
+	if !cmp.Equal(got, allMessages()[:10]) {
+		t.Errorf("%v", cmp.Diff(allMessages()[:10], got))
+	}
+}
+
+func TestDecodeParallel(t *testing.T) {
+	h := &recordingHandler{}
+
+	err := DecodeParallel(bytes.NewReader(encodeMessages(testMessages(), false)), Configuration{Workers: 2}, h)
+	if err != nil {
+		t.Fatalf("DecodeParallel() error = %v", err)
+	}
+
+	if !cmp.Equal(h.messages, testMessages()) {
+		t.Errorf("%v", cmp.Diff(testMessages(), h.messages))
+	}
+}
+
+func TestDecodeParallel_Errors(t *testing.T) {
+	data := encodeMessages(testMessages(), true)
+
+	// Change the type of the second message so it fails to parse
+	data[2+systemEventSize+2] = 'Z'
+
+	h := &recordingHandler{}
+
+	if err := DecodeParallel(bytes.NewReader(data), Configuration{LengthFieldPrefixed: true}, h); err == nil {
+		t.Errorf("DecodeParallel() expected error")
+	}
+
+	// The remaining messages are still decoded
+	if want := slices.Delete(testMessages(), 1, 2); !cmp.Equal(h.messages, want) {
+		t.Errorf("%v", cmp.Diff(want, h.messages))
+	}
+}
+
+func BenchmarkDecodeParallel(b *testing.B) {
+	data, count := benchmarkData(b)
+	config := Configuration{LengthFieldPrefixed: true}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := DecodeParallel(bytes.NewReader(data), config, NopHandler{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	reportMessageRate(b, data, count)
+}
+
+func TestParseFileParallel_ErrorPolicy(t *testing.T) {
+	messages := parallelMessages()
+	data := encodeMessages(messages, true)
+
+	// Change the type of a message in the last chunk so it fails to parse
+	index := len(messages) - 2
+	offset := len(data) - (2 + orderAddSize) - (2 + systemEventSize)
+	data[offset+2] = 'Z'
+
+	path := filepath.Join(t.TempDir(), "01302020.NASDAQ_ITCH50")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy       ErrorPolicy
+		wantMessages []ItchMessage
+		wantErr      bool
+	}{
+		{ERROR_POLICY_COLLECT, slices.Delete(slices.Clone(messages), index, index+1), true},
+		{ERROR_POLICY_STOP, messages[:index], true},
+		{ERROR_POLICY_SKIP, slices.Delete(slices.Clone(messages), index, index+1), false},
+	}
+	for _, tt := range tests {
+		got, err := ParseFileParallel(path, Configuration{LengthFieldPrefixed: true, Workers: 4, ErrorPolicy: tt.policy})
+
+		if !cmp.Equal(got, tt.wantMessages) {
+			t.Errorf("ParseFileParallel(%v) returned %d messages, want %d", tt.policy, len(got), len(tt.wantMessages))
+		}
+
+		var decodeErr DecodeError
+		if tt.wantErr && (!errors.As(err, &decodeErr) || decodeErr.Offset != int64(offset) || decodeErr.Index != index) {
+			t.Errorf("ParseFileParallel(%v) error = %v, want DecodeError at offset %d", tt.policy, err, offset)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("ParseFileParallel(%v) error = %v, want nil", tt.policy, err)
+		}
+	}
+}
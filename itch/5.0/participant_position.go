@@ -7,17 +7,12 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 )
 
 type MMMode uint8
 type MMState uint8
 
-var (
-	MarketParticipants = make(map[string][]ParticipantPosition)
-)
-
 const (
 	MMMODE_NORMAL        MMMode = 'N'
 	MMMODE_PASSIVE       MMMode = 'P'
@@ -33,75 +28,111 @@ const (
 )
 
 type ParticipantPosition struct {
-	Timestamp      time.Duration
-	Mpid           string
-	Stock          string
-	StockLocate    uint16
-	TrackingNumber uint16
-	PrimaryMM      bool
-	Mode           MMMode
-	State          MMState
+	Timestamp      time.Duration `json:"timestamp"`
+	Mpid           string        `json:"mpid"`
+	Stock          string        `json:"stock"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	PrimaryMM      bool          `json:"primaryMM"`
+	Mode           MMMode        `json:"mode"`
+	State          MMState       `json:"state"`
 }
 
 func (p ParticipantPosition) Type() uint8 {
 	return MESSAGE_PARTICIPANT_POSITION
 }
 
-func (p ParticipantPosition) Bytes() []byte {
-	data := make([]byte, participantPositionSize)
+func (p ParticipantPosition) Header() Header {
+	return Header{Type: MESSAGE_PARTICIPANT_POSITION, StockLocate: p.StockLocate, TrackingNumber: p.TrackingNumber, Timestamp: p.Timestamp}
+}
 
-	data[0] = MESSAGE_PARTICIPANT_POSITION
-	binary.BigEndian.PutUint16(data[1:3], p.StockLocate)
+func (p ParticipantPosition) Symbol() Symbol {
+	return NewSymbol(p.Stock)
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(p.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], p.TrackingNumber)
+func (p ParticipantPosition) Bytes() []byte {
+	return p.appendBinary(make([]byte, 0, participantPositionSize))
+}
 
-	copy(data[11:15], []byte(fmt.Sprintf("%-4s", p.Mpid)))
-	copy(data[15:23], []byte(fmt.Sprintf("%-8s", p.Stock)))
+func (p ParticipantPosition) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(make([]byte, 0, participantPositionSize))
+}
 
-	if p.PrimaryMM {
-		data[23] = 'Y'
-	} else {
-		data[23] = 'N'
+func (p ParticipantPosition) AppendBinary(dst []byte) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return dst, err
 	}
 
-	data[24] = byte(p.Mode)
-	data[25] = byte(p.State)
+	return p.appendBinary(dst), nil
+}
+
+func (p ParticipantPosition) MarshalJSON() ([]byte, error) {
+	type message ParticipantPosition
+	return marshalMessageJSON(MESSAGE_PARTICIPANT_POSITION, message(p))
+}
+
+func (p *ParticipantPosition) UnmarshalJSON(data []byte) error {
+	type message ParticipantPosition
+	return unmarshalMessageJSON(data, MESSAGE_PARTICIPANT_POSITION, (*message)(p))
+}
+
+func (p ParticipantPosition) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_PARTICIPANT_POSITION, p.StockLocate, p.TrackingNumber, p.Timestamp)
+	dst = appendAlpha(dst, p.Mpid, 4)
+	dst = appendAlpha(dst, p.Stock, 8)
+	dst = appendBool(dst, p.PrimaryMM)
+	dst = append(dst, byte(p.Mode), byte(p.State))
+
+	return dst
+}
+
+func (p ParticipantPosition) validate() error {
+	v := validator{messageType: MESSAGE_PARTICIPANT_POSITION}
+	v.timestamp("Timestamp", p.Timestamp)
+	v.alpha("Mpid", p.Mpid, 4)
+	v.alpha("Stock", p.Stock, 8)
+	v.enum("Mode", p.Mode.valid(), uint8(p.Mode))
+	v.enum("State", p.State.valid(), uint8(p.State))
 
-	return data
+	return v.err
 }
 
 func ParseParticipantPosition(data []byte) (ParticipantPosition, error) {
+	var p ParticipantPosition
+	err := p.UnmarshalBinary(data)
+	return p, err
+}
+
+func (p *ParticipantPosition) UnmarshalBinary(data []byte) error {
+	return p.decode(data, nil)
+}
+
+func (p *ParticipantPosition) decode(data []byte, symbols symbolTable) error {
 	if len(data) != participantPositionSize {
-		return ParticipantPosition{}, NewInvalidPacketSize(participantPositionSize, len(data))
+		return NewInvalidPacketSize(participantPositionSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	primary := false
 	if data[23] == 'Y' {
 		primary = true
 	}
 
-	pp := ParticipantPosition{
+	*p = ParticipantPosition{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
-		Mpid:           strings.TrimSpace(string(data[11:15])),
-		Stock:          strings.TrimSpace(string(data[15:23])),
+		Timestamp:      timestamp,
+		Mpid:           symbols.get(data[11:15]),
+		Stock:          symbols.get(data[15:23]),
 		PrimaryMM:      primary,
 		Mode:           MMMode(data[24]),
 		State:          MMState(data[25]),
 	}
 
-	MarketParticipants[pp.Mpid] = append(MarketParticipants[pp.Mpid], pp)
-
-	return pp, nil
+	return nil
 }
 
 func (p ParticipantPosition) String() string {
@@ -136,6 +167,23 @@ func (m MMMode) String() string {
 	return "Unknown MMMode"
 }
 
+func (m MMMode) valid() bool {
+	switch m {
+	case MMMODE_NORMAL, MMMODE_PASSIVE, MMMODE_SYNDICATE, MMMODE_PRE_SYNDICATE, MMMODE_PENALTY:
+		return true
+	}
+
+	return false
+}
+
+func (m MMMode) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(m)), m.String())
+}
+
+func (m *MMMode) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(m))
+}
+
 func (m MMState) String() string {
 	switch m {
 	case MMSTATE_ACTIVE:
@@ -152,3 +200,20 @@ func (m MMState) String() string {
 
 	return "Unknown MMState"
 }
+
+func (m MMState) valid() bool {
+	switch m {
+	case MMSTATE_ACTIVE, MMSTATE_EXCUSED, MMSTATE_WITHDRAWN, MMSTATE_SUSPENDED, MMSTATE_DELETED:
+		return true
+	}
+
+	return false
+}
+
+func (m MMState) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(m)), m.String())
+}
+
+func (m *MMState) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(m))
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"sync"
+	"time"
+)
+
+// phases are the System Event codes in the order they occur during a day
+var phases = [...]EventCode{
+	EVENT_START_MESSAGES,
+	EVENT_START_HOURS,
+	EVENT_START_MARKET,
+	EVENT_END_MARKET,
+	EVENT_END_HOURS,
+	EVENT_END_MESSAGES,
+}
+
+// PhaseChange is a transition between phases of the feed reported by a PhaseTracker
+type PhaseChange struct {
+	// From is 0 for the first System Event
+	From      EventCode
+	To        EventCode
+	Timestamp time.Duration
+}
+
+// PhaseTracker tracks the phase of the day from System Event messages, so logic can be gated on regular or
+// extended market hours using the feed rather than the wall clock. The phase is the EventCode of the latest
+// System Event, or 0 before the first one.
+//
+// Events must arrive in the order Start of Messages, Start of System Hours, Start of Market Hours, End of
+// Market Hours, End of System Hours, End of Messages. An event that skips phases is applied but reported as
+// an ErrInvalidPhaseTransition, since a feed may be read from part way through the day. An event for the
+// current or an earlier phase is reported and ignored.
+//
+// PhaseTracker implements Handler so it can be passed to DecodeTo or used with Dispatch. It is safe for
+// concurrent use.
+type PhaseTracker struct {
+	NopHandler
+
+	mu       sync.RWMutex
+	phase    EventCode
+	times    [len(phases)]time.Duration
+	observed [len(phases)]bool
+	err      error
+	callback func(PhaseChange)
+}
+
+// NewPhaseTracker creates a PhaseTracker that calls callback on every phase transition. callback may be nil
+func NewPhaseTracker(callback func(PhaseChange)) *PhaseTracker {
+	return &PhaseTracker{callback: callback}
+}
+
+// Apply updates the phase with a single ITCH message, returning an ErrInvalidPhaseTransition if a System Event
+// is out of order. Messages other than System Event are ignored
+func (t *PhaseTracker) Apply(msg ItchMessage) error {
+	if m, ok := msg.(SystemEvent); ok {
+		return t.apply(m)
+	}
+
+	return nil
+}
+
+// OnSystemEvent updates the phase. The first out of order event is available from Err
+func (t *PhaseTracker) OnSystemEvent(m SystemEvent) {
+	_ = t.apply(m)
+}
+
+func (t *PhaseTracker) apply(m SystemEvent) error {
+	t.mu.Lock()
+
+	from := t.phase
+	current, next := phaseIndex(from), phaseIndex(m.EventCode)
+
+	var err error
+	switch {
+	case next < 0 || next <= current:
+		err = NewInvalidPhaseTransition(from, m.EventCode)
+	case next != current+1:
+		// Skipped phases are still applied
+		err = NewInvalidPhaseTransition(from, m.EventCode)
+		fallthrough
+	default:
+		t.phase = m.EventCode
+		t.times[next] = m.Timestamp
+		t.observed[next] = true
+	}
+
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+
+	changed := t.phase != from
+
+	t.mu.Unlock()
+
+	if changed && t.callback != nil {
+		t.callback(PhaseChange{From: from, To: m.EventCode, Timestamp: m.Timestamp})
+	}
+
+	return err
+}
+
+// phaseIndex returns the position of an event in phases, -1 for 0 and -2 for an unknown event
+func phaseIndex(e EventCode) int {
+	if e == 0 {
+		return -1
+	}
+
+	for i, phase := range phases {
+		if phase == e {
+			return i
+		}
+	}
+
+	return -2
+}
+
+// Phase returns the EventCode of the current phase, or 0 before the first System Event
+func (t *PhaseTracker) Phase() EventCode {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.phase
+}
+
+// Time returns when the phase started by event began. It is false if the phase has not been reached or if
+// its System Event was never received, such as when the feed is read from part way through the day
+func (t *PhaseTracker) Time(event EventCode) (time.Duration, bool) {
+	i := phaseIndex(event)
+	if i < 0 {
+		return 0, false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.times[i], t.observed[i]
+}
+
+// RegularHours reports whether the market is open for regular trading hours
+func (t *PhaseTracker) RegularHours() bool {
+	return t.Phase() == EVENT_START_MARKET
+}
+
+// ExtendedHours reports whether the system is open outside of regular market hours, before the open or after
+// the close
+func (t *PhaseTracker) ExtendedHours() bool {
+	phase := t.Phase()
+	return phase == EVENT_START_HOURS || phase == EVENT_END_MARKET
+}
+
+// SystemHours reports whether the system is open, during either regular or extended hours
+func (t *PhaseTracker) SystemHours() bool {
+	phase := t.Phase()
+	return phase == EVENT_START_HOURS || phase == EVENT_START_MARKET || phase == EVENT_END_MARKET
+}
+
+// Err returns the first out of order System Event received through OnSystemEvent or Apply
+func (t *PhaseTracker) Err() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.err
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func systemEvents(codes ...EventCode) []SystemEvent {
+	events := []SystemEvent{}
+	for i, code := range codes {
+		events = append(events, SystemEvent{Timestamp: time.Duration(i+3) * time.Hour, EventCode: code})
+	}
+
+	return events
+}
+
+func TestPhaseTracker(t *testing.T) {
+	changes := []PhaseChange{}
+	tracker := NewPhaseTracker(func(c PhaseChange) {
+		changes = append(changes, c)
+	})
+
+	type hours struct{ system, regular, extended bool }
+
+	want := map[EventCode]hours{
+		EVENT_START_MESSAGES: {false, false, false},
+		EVENT_START_HOURS:    {true, false, true},
+		EVENT_START_MARKET:   {true, true, false},
+		EVENT_END_MARKET:     {true, false, true},
+		EVENT_END_HOURS:      {false, false, false},
+		EVENT_END_MESSAGES:   {false, false, false},
+	}
+
+	if tracker.Phase() != 0 || tracker.SystemHours() {
+		t.Errorf("Phase() = %q before the first event", tracker.Phase())
+	}
+
+	events := systemEvents(phases[:]...)
+	for _, e := range events {
+		if err := tracker.Apply(e); err != nil {
+			t.Fatalf("Apply(%v) error = %v", e.EventCode, err)
+		}
+
+		got := hours{tracker.SystemHours(), tracker.RegularHours(), tracker.ExtendedHours()}
+		if tracker.Phase() != e.EventCode || got != want[e.EventCode] {
+			t.Errorf("after %q Phase() = %q, hours = %+v, want %+v", e.EventCode, tracker.Phase(), got, want[e.EventCode])
+		}
+	}
+
+	if len(changes) != len(phases) || changes[0].From != 0 || changes[2] != (PhaseChange{From: EVENT_START_HOURS, To: EVENT_START_MARKET, Timestamp: 5 * time.Hour}) {
+		t.Errorf("changes = %v", changes)
+	}
+
+	if open, ok := tracker.Time(EVENT_START_MARKET); !ok || open != 5*time.Hour {
+		t.Errorf("Time(START_MARKET) = %v, %v", open, ok)
+	}
+
+	if err := tracker.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestPhaseTracker_InvalidOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		events    []EventCode
+		wantPhase EventCode
+		wantFrom  EventCode
+		wantTo    EventCode
+	}{
+		{"skipped phase", []EventCode{EVENT_START_MESSAGES, EVENT_START_MARKET}, EVENT_START_MARKET, EVENT_START_MESSAGES, EVENT_START_MARKET},
+		{"starts part way through", []EventCode{EVENT_END_MARKET, EVENT_END_HOURS}, EVENT_END_HOURS, 0, EVENT_END_MARKET},
+		{"repeated", []EventCode{EVENT_START_MESSAGES, EVENT_START_MESSAGES}, EVENT_START_MESSAGES, EVENT_START_MESSAGES, EVENT_START_MESSAGES},
+		{"backwards", []EventCode{EVENT_START_MESSAGES, EVENT_START_HOURS, EVENT_START_MESSAGES}, EVENT_START_HOURS, EVENT_START_HOURS, EVENT_START_MESSAGES},
+		{"unknown", []EventCode{EVENT_START_MESSAGES, 'Z'}, EVENT_START_MESSAGES, EVENT_START_MESSAGES, 'Z'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewPhaseTracker(nil)
+			for _, e := range systemEvents(tt.events...) {
+				tracker.OnSystemEvent(e)
+			}
+
+			if tracker.Phase() != tt.wantPhase {
+				t.Errorf("Phase() = %q, want %q", tracker.Phase(), tt.wantPhase)
+			}
+
+			var transitionErr ErrInvalidPhaseTransition
+			if err := tracker.Err(); !errors.As(err, &transitionErr) || transitionErr.From != tt.wantFrom || transitionErr.To != tt.wantTo {
+				t.Errorf("Err() = %v, want transition from %q to %q", err, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestPhaseTracker_DecodeTo(t *testing.T) {
+	changes := []EventCode{}
+	tracker := NewPhaseTracker(func(c PhaseChange) {
+		changes = append(changes, c.To)
+	})
+
+	if err := DecodeTo(bytes.NewReader(encodeMessages(testMessages(), true)), Configuration{LengthFieldPrefixed: true}, tracker); err != nil {
+		t.Fatalf("DecodeTo() error = %v", err)
+	}
+
+	// testMessages skips from Start of Messages to End of Messages
+	want := []EventCode{EVENT_START_MESSAGES, EVENT_END_MESSAGES}
+	if !cmp.Equal(changes, want) || tracker.Err() == nil {
+		t.Errorf("changes = %q, Err() = %v", changes, tracker.Err())
+	}
+}
+
+func TestPhaseTracker_Time(t *testing.T) {
+	// The feed is joined part way through the day, so the earlier phases are never observed
+	tracker := NewPhaseTracker(nil)
+	for _, e := range systemEvents(EVENT_START_MARKET, EVENT_END_MARKET) {
+		tracker.OnSystemEvent(e)
+	}
+
+	tests := []struct {
+		event  EventCode
+		want   time.Duration
+		wantOK bool
+	}{
+		{EVENT_START_MESSAGES, 0, false},
+		{EVENT_START_HOURS, 0, false},
+		{EVENT_START_MARKET, 3 * time.Hour, true},
+		{EVENT_END_MARKET, 4 * time.Hour, true},
+		{EVENT_END_HOURS, 0, false},
+		{EVENT_END_MESSAGES, 0, false},
+		{'Z', 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := tracker.Time(tt.event); got != tt.want || ok != tt.wantOK {
+			t.Errorf("Time(%q) = %v, %v, want %v, %v", tt.event, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
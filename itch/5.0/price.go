@@ -1,33 +1,41 @@
 package itch
 
 import (
-	"bytes"
-	"encoding/binary"
-	"unicode"
-
 	"github.com/quagmt/udecimal"
 )
 
+// maxPrice is the maximum value of both Price (4) and Price (8)
+var maxPrice = udecimal.MustFromUint64(200_000, 0)
+
+var pow10 = [...]uint64{1, 10, 100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000, 100_000_000}
+
 func bytesToPrice(data []byte, precision uint8) (udecimal.Decimal, error) {
 	// Prices are integer fields, supplied with an associated precision.
 	// When converted to a decimal format, prices are in fixed point format,
 	// where the precision defines the number of decimal places.
 	// For example, a field flagged as Price (4) has an implied 4 decimal places.
 	// The maximum value of price (4) in TotalViewITCH is 200,000.0000 (decimal, 77359400 hex)
-	data = bytes.TrimLeftFunc(data, unicode.IsSpace)
-	value := binary.BigEndian.Uint64(append(make([]byte, 8-len(data)), data...))
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+
 	return udecimal.NewFromUint64(value, precision)
 }
 
-func priceToBytes(price udecimal.Decimal, precision uint8) ([]byte, error) {
-	price, _ = price.Div(udecimal.MustFromInt64(1, precision))
-	p, _ := price.Int64()
+// appendPrice appends price as a big endian integer of precision bytes, the same width as its number of implied
+// decimal places. Digits beyond precision are truncated, see validPrice
+func appendPrice(dst []byte, price udecimal.Decimal, precision uint8) []byte {
+	p, _ := price.Mul64(pow10[precision]).Trunc(0).Int64()
 
-	bytes := make([]byte, precision)
-	for i := 0; i < int(precision); i++ {
-		shift := uint((int(precision) - 1 - i) * 8)
-		bytes[i] = byte(p >> shift)
+	for i := int(precision) - 1; i >= 0; i-- {
+		dst = append(dst, byte(uint64(p)>>(8*i)))
 	}
 
-	return bytes, nil
+	return dst
+}
+
+// validPrice reports whether price can be encoded with precision decimal places without losing digits
+func validPrice(price udecimal.Decimal, precision uint8) bool {
+	return !price.IsNeg() && !price.GreaterThan(maxPrice) && price.Trunc(precision).Equal(price)
 }
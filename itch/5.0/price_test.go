@@ -48,30 +48,48 @@ func Test_bytesToPrice(t *testing.T) {
 		{
 			name: "test correct convert precision 4 with right padded zeroes",
 			args: args{
-				data:      []byte{0x20, 0x03, 0x45, 0xE4},
+				data:      []byte{0x00, 0x03, 0x45, 0xE4},
 				precision: 4,
 			},
 			want:    udecimal.MustParse("21.4500"),
 			wantErr: false,
 		},
 		{
-			name: "test correct convert precision 4 with left padded spaces",
+			name: "test correct convert precision 4 with leading zero bytes",
 			args: args{
-				data:      []byte{0x20, 0x20, 0x3A, 0x98},
+				data:      []byte{0x00, 0x00, 0x3A, 0x98},
 				precision: 4,
 			},
 			want:    udecimal.MustParse("1.5000"),
 			wantErr: false,
 		},
 		{
-			name: "test correct convert precision 8 with left padded spaces",
+			name: "test correct convert precision 8 with leading zero bytes",
 			args: args{
-				data:      []byte{0x20, 0x20, 0x20, 0x7F, 0x04, 0x99, 0x44, 0x80},
+				data:      []byte{0x00, 0x00, 0x00, 0x7F, 0x04, 0x99, 0x44, 0x80},
 				precision: 8,
 			},
 			want:    udecimal.MustParse("5455.38000000"),
 			wantErr: false,
 		},
+		{
+			name: "test correct convert precision 4 with leading tab byte",
+			args: args{
+				data:      []byte{0x09, 0x00, 0x13, 0xC0},
+				precision: 4,
+			},
+			want:    udecimal.MustParse("15100.0000"),
+			wantErr: false,
+		},
+		{
+			name: "test correct convert precision 4 with leading space byte",
+			args: args{
+				data:      []byte{0x20, 0x01, 0xF8, 0x40},
+				precision: 4,
+			},
+			want:    udecimal.MustParse("53700.0000"),
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -88,16 +106,15 @@ func Test_bytesToPrice(t *testing.T) {
 	}
 }
 
-func Test_priceToBytes(t *testing.T) {
+func Test_appendPrice(t *testing.T) {
 	type args struct {
 		price     udecimal.Decimal
 		precision uint8
 	}
 	tests := []struct {
-		name    string
-		args    args
-		want    []byte
-		wantErr bool
+		name string
+		args args
+		want []byte
 	}{
 		{
 			name: "test correct convert precision 4",
@@ -105,8 +122,7 @@ func Test_priceToBytes(t *testing.T) {
 				price:     udecimal.MustParse("7738.7344"),
 				precision: 4,
 			},
-			want:    []byte{0x04, 0x9C, 0xD6, 0x50},
-			wantErr: false,
+			want: []byte{0x04, 0x9C, 0xD6, 0x50},
 		},
 		{
 			name: "test correct convert precision 4 with right padded zeroes",
@@ -114,38 +130,82 @@ func Test_priceToBytes(t *testing.T) {
 				price:     udecimal.MustParse("21.4500"),
 				precision: 4,
 			},
-			want:    []byte{0x20, 0x03, 0x45, 0xE4},
-			wantErr: false,
+			want: []byte{0x00, 0x03, 0x45, 0xE4},
 		},
 		{
-			name: "test correct convert precision 4 with left padded spaces",
+			name: "test correct convert precision 4 with leading zero bytes",
 			args: args{
 				price:     udecimal.MustParse("1.5000"),
 				precision: 4,
 			},
-			want:    []byte{0x20, 0x20, 0x3A, 0x98},
-			wantErr: false,
+			want: []byte{0x00, 0x00, 0x3A, 0x98},
 		},
 		{
-			name: "test correct convert precision 8 with left padded spaces",
+			name: "test correct convert precision 8 with leading zero bytes",
 			args: args{
 				price:     udecimal.MustParse("5455.38000000"),
 				precision: 8,
 			},
-			want:    []byte{0x20, 0x20, 0x20, 0x7F, 0x04, 0x99, 0x44, 0x80},
-			wantErr: false,
+			want: []byte{0x00, 0x00, 0x00, 0x7F, 0x04, 0x99, 0x44, 0x80},
+		},
+		{
+			name: "test correct convert maximum price",
+			args: args{
+				price:     udecimal.MustParse("200000"),
+				precision: 4,
+			},
+			want: []byte{0x77, 0x35, 0x94, 0x00},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := priceToBytes(tt.args.price, tt.args.precision)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("priceToBytes() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
+			got := appendPrice(nil, tt.args.price, tt.args.precision)
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("priceToBytes() = %v, want %v", got, tt.want)
+				t.Errorf("appendPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validPrice(t *testing.T) {
+	tests := []struct {
+		price     string
+		precision uint8
+		want      bool
+	}{
+		{"0", 4, true},
+		{"7738.7344", 4, true},
+		{"200000", 4, true},
+		{"200000.0001", 4, false},
+		{"1.23456", 4, false},
+		{"1.23456", 8, true},
+		{"-1", 4, false},
+	}
+
+	for _, tt := range tests {
+		if got := validPrice(udecimal.MustParse(tt.price), tt.precision); got != tt.want {
+			t.Errorf("validPrice(%v, %v) = %v, want %v", tt.price, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func Test_priceRoundTrip(t *testing.T) {
+	for _, price := range []string{"15100", "53700", "199999.9999", "200000"} {
+		t.Run(price, func(t *testing.T) {
+			want := OrderAdd{Stock: "AAPL", Reference: 1, Shares: 100, Price: udecimal.MustParse(price), OrderIndicator: ORDER_INDICATOR_BUY}
+
+			data, err := want.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+
+			got, err := ParseOrderAdd(data)
+			if err != nil {
+				t.Fatalf("ParseOrderAdd() error = %v", err)
+			}
+			if !got.Price.Equal(want.Price) {
+				t.Errorf("ParseOrderAdd() price = %v, want %v", got.Price, want.Price)
 			}
 		})
 	}
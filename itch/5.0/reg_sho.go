@@ -7,7 +7,6 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 )
 
@@ -20,52 +19,96 @@ const (
 )
 
 type RegSho struct {
-	Stock          string
-	Timestamp      time.Duration
-	StockLocate    uint16
-	TrackingNumber uint16
-	Action         RegShoAction
+	Stock          string        `json:"stock"`
+	Timestamp      time.Duration `json:"timestamp"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	Action         RegShoAction  `json:"action"`
 }
 
 func (r RegSho) Type() uint8 {
 	return MESSAGE_REG_SHO
 }
 
+func (r RegSho) Header() Header {
+	return Header{Type: MESSAGE_REG_SHO, StockLocate: r.StockLocate, TrackingNumber: r.TrackingNumber, Timestamp: r.Timestamp}
+}
+
+func (r RegSho) Symbol() Symbol {
+	return NewSymbol(r.Stock)
+}
+
 func (r RegSho) Bytes() []byte {
-	data := make([]byte, regShoSize)
+	return r.appendBinary(make([]byte, 0, regShoSize))
+}
 
-	data[0] = MESSAGE_REG_SHO
-	binary.BigEndian.PutUint16(data[1:3], r.StockLocate)
+func (r RegSho) MarshalBinary() ([]byte, error) {
+	return r.AppendBinary(make([]byte, 0, regShoSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(r.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], r.TrackingNumber)
+func (r RegSho) AppendBinary(dst []byte) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return dst, err
+	}
 
-	copy(data[11:19], []byte(fmt.Sprintf("%-8s", r.Stock)))
+	return r.appendBinary(dst), nil
+}
+
+func (r RegSho) MarshalJSON() ([]byte, error) {
+	type message RegSho
+	return marshalMessageJSON(MESSAGE_REG_SHO, message(r))
+}
+
+func (r *RegSho) UnmarshalJSON(data []byte) error {
+	type message RegSho
+	return unmarshalMessageJSON(data, MESSAGE_REG_SHO, (*message)(r))
+}
+
+func (r RegSho) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_REG_SHO, r.StockLocate, r.TrackingNumber, r.Timestamp)
+	dst = appendAlpha(dst, r.Stock, 8)
+	dst = append(dst, byte(r.Action))
+
+	return dst
+}
 
-	data[19] = byte(r.Action)
+func (r RegSho) validate() error {
+	v := validator{messageType: MESSAGE_REG_SHO}
+	v.timestamp("Timestamp", r.Timestamp)
+	v.alpha("Stock", r.Stock, 8)
+	v.enum("Action", r.Action.valid(), uint8(r.Action))
 
-	return data
+	return v.err
 }
 
 func ParseRegSho(data []byte) (RegSho, error) {
+	var r RegSho
+	err := r.UnmarshalBinary(data)
+	return r, err
+}
+
+func (r *RegSho) UnmarshalBinary(data []byte) error {
+	return r.decode(data, nil)
+}
+
+func (r *RegSho) decode(data []byte, symbols symbolTable) error {
 	if len(data) != regShoSize {
-		return RegSho{}, NewInvalidPacketSize(regShoSize, len(data))
+		return NewInvalidPacketSize(regShoSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return RegSho{
+	*r = RegSho{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
-		Stock:          strings.TrimSpace(string(data[11:19])),
+		Timestamp:      timestamp,
+		Stock:          symbols.get(data[11:19]),
 		Action:         RegShoAction(data[19]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (r RegSho) String() string {
@@ -92,3 +135,20 @@ func (a RegShoAction) String() string {
 
 	return "Unknown RegShoAction"
 }
+
+func (a RegShoAction) valid() bool {
+	switch a {
+	case REGSHO_NO_PRICE_TEST, REGSHO_INTRADAY_DROP, REGSHO_REMAINS:
+		return true
+	}
+
+	return false
+}
+
+func (a RegShoAction) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(a)), a.String())
+}
+
+func (a *RegShoAction) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(a))
+}
@@ -7,7 +7,6 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 )
 
@@ -21,52 +20,96 @@ const (
 )
 
 type Rpii struct {
-	Stock          string
-	Timestamp      time.Duration
-	StockLocate    uint16
-	TrackingNumber uint16
-	InterestFlag   RpiInterestFlag
+	Stock          string          `json:"stock"`
+	Timestamp      time.Duration   `json:"timestamp"`
+	StockLocate    uint16          `json:"stockLocate"`
+	TrackingNumber uint16          `json:"trackingNumber"`
+	InterestFlag   RpiInterestFlag `json:"interestFlag"`
 }
 
 func (r Rpii) Type() uint8 {
 	return MESSAGE_RPII
 }
 
+func (r Rpii) Header() Header {
+	return Header{Type: MESSAGE_RPII, StockLocate: r.StockLocate, TrackingNumber: r.TrackingNumber, Timestamp: r.Timestamp}
+}
+
+func (r Rpii) Symbol() Symbol {
+	return NewSymbol(r.Stock)
+}
+
 func (r Rpii) Bytes() []byte {
-	data := make([]byte, rpiiSize)
+	return r.appendBinary(make([]byte, 0, rpiiSize))
+}
 
-	data[0] = MESSAGE_RPII
-	binary.BigEndian.PutUint16(data[1:3], r.StockLocate)
+func (r Rpii) MarshalBinary() ([]byte, error) {
+	return r.AppendBinary(make([]byte, 0, rpiiSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(r.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], r.TrackingNumber)
+func (r Rpii) AppendBinary(dst []byte) ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return dst, err
+	}
 
-	copy(data[11:19], []byte(fmt.Sprintf("%-8s", r.Stock)))
+	return r.appendBinary(dst), nil
+}
+
+func (r Rpii) MarshalJSON() ([]byte, error) {
+	type message Rpii
+	return marshalMessageJSON(MESSAGE_RPII, message(r))
+}
+
+func (r *Rpii) UnmarshalJSON(data []byte) error {
+	type message Rpii
+	return unmarshalMessageJSON(data, MESSAGE_RPII, (*message)(r))
+}
+
+func (r Rpii) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_RPII, r.StockLocate, r.TrackingNumber, r.Timestamp)
+	dst = appendAlpha(dst, r.Stock, 8)
+	dst = append(dst, byte(r.InterestFlag))
+
+	return dst
+}
 
-	data[19] = byte(r.InterestFlag)
+func (r Rpii) validate() error {
+	v := validator{messageType: MESSAGE_RPII}
+	v.timestamp("Timestamp", r.Timestamp)
+	v.alpha("Stock", r.Stock, 8)
+	v.enum("InterestFlag", r.InterestFlag.valid(), uint8(r.InterestFlag))
 
-	return data
+	return v.err
 }
 
 func ParseRpii(data []byte) (Rpii, error) {
+	var r Rpii
+	err := r.UnmarshalBinary(data)
+	return r, err
+}
+
+func (r *Rpii) UnmarshalBinary(data []byte) error {
+	return r.decode(data, nil)
+}
+
+func (r *Rpii) decode(data []byte, symbols symbolTable) error {
 	if len(data) != rpiiSize {
-		return Rpii{}, NewInvalidPacketSize(rpiiSize, len(data))
+		return NewInvalidPacketSize(rpiiSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return Rpii{
+	*r = Rpii{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
-		Stock:          strings.TrimSpace(string(data[11:19])),
+		Timestamp:      timestamp,
+		Stock:          symbols.get(data[11:19]),
 		InterestFlag:   RpiInterestFlag(data[19]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (n Rpii) String() string {
@@ -95,3 +138,20 @@ func (i RpiInterestFlag) String() string {
 
 	return "Unknown RpiInterestFlag"
 }
+
+func (i RpiInterestFlag) valid() bool {
+	switch i {
+	case RPI_INTEREST_BUY, RPI_INTEREST_SELL, RPI_INTEREST_BOTH, RPI_INTEREST_NONE:
+		return true
+	}
+
+	return false
+}
+
+func (i RpiInterestFlag) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(i)), i.String())
+}
+
+func (i *RpiInterestFlag) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(i))
+}
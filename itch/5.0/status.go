@@ -0,0 +1,366 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+type StatusChangeKind uint8
+
+const (
+	STATUS_CHANGE_TRADING_STATE StatusChangeKind = iota + 1
+	STATUS_CHANGE_REG_SHO
+	STATUS_CHANGE_OPERATIONAL_HALT
+	STATUS_CHANGE_LULD_COLLAR
+	STATUS_CHANGE_MWCB_LEVEL
+	STATUS_CHANGE_MWCB_BREACH
+)
+
+// SecurityStatus is the trading status of a single stock, built from its Stock Trading Action, Reg SHO,
+// Operational Halt and LULD Auction Collar messages. Each part records the Timestamp of the message that
+// last changed it and is zero until the first such message
+type SecurityStatus struct {
+	Stock       string
+	StockLocate uint16
+
+	TradingState       TradingState
+	TradingStateReason string
+	TradingStateTime   time.Duration
+
+	RegSho     RegShoAction
+	RegShoTime time.Duration
+
+	// OperationalHalts holds the markets where the stock is under an operational halt
+	OperationalHalts    []MarketCode
+	OperationalHaltTime time.Duration
+
+	// LuldCollar is the latest LULD Auction Collar message. Its Timestamp is zero if there has been none
+	LuldCollar LuldCollar
+}
+
+// Tradable reports whether the stock is trading on Nasdaq and not under an operational halt there.
+// It only considers the stock's own status. Use StatusTracker.Tradable to also account for a market
+// wide circuit breaker halt
+func (s SecurityStatus) Tradable() bool {
+	return s.TradingState == STATE_TRADING && !s.OperationallyHalted(MARKET_CODE_NASDAQ)
+}
+
+// Halted reports whether trading in the stock is halted across all U.S. markets
+func (s SecurityStatus) Halted() bool {
+	return s.TradingState == STATE_HALTED
+}
+
+// Paused reports whether trading in the stock is paused, such as by a LULD trading pause
+func (s SecurityStatus) Paused() bool {
+	return s.TradingState == STATE_PAUSED
+}
+
+// Quoting reports whether the stock is in a quotation only period before trading resumes
+func (s SecurityStatus) Quoting() bool {
+	return s.TradingState == STATE_QUOTATION
+}
+
+// ShortSaleRestricted reports whether the Reg SHO short sale price test restriction is in effect
+func (s SecurityStatus) ShortSaleRestricted() bool {
+	return s.RegSho == REGSHO_INTRADAY_DROP || s.RegSho == REGSHO_REMAINS
+}
+
+// OperationallyHalted reports whether the stock is under an operational halt on a market
+func (s SecurityStatus) OperationallyHalted(market MarketCode) bool {
+	return slices.Contains(s.OperationalHalts, market)
+}
+
+// MarketStatus is the market wide circuit breaker status, built from MWCB Decline Level and MWCB Status messages
+type MarketStatus struct {
+	// Levels is the latest MWCB Decline Level message. Its Timestamp is zero if there has been none
+	Levels MwcbLevel
+
+	// BreachedLevel is the highest level breached, '1', '2' or '3', or 0 if no level has been breached
+	BreachedLevel BreachedLevel
+	BreachedTime  time.Duration
+}
+
+// Halted reports whether a Level 3 breach has halted trading in every stock for the rest of the day.
+// Level 1 and 2 breaches halt trading for a limited time, which Nasdaq reports through the Stock Trading
+// Action messages of each stock, so they are reflected in SecurityStatus instead
+func (s MarketStatus) Halted() bool {
+	return s.BreachedLevel == BREACHED_LEVEL_3
+}
+
+// StatusChange is a transition reported by a StatusTracker
+type StatusChange struct {
+	Kind      StatusChangeKind
+	Timestamp time.Duration
+
+	// Security is the status of the stock after the change. It is zero for MWCB changes
+	Security SecurityStatus
+	// Market is the market wide status after the change
+	Market MarketStatus
+	// Message is the ITCH message that caused the change
+	Message ItchMessage
+}
+
+// StatusTracker tracks the trading status of every stock and the market wide circuit breakers from the
+// administrative messages. It answers whether a stock is tradable, halted, paused or quoting, whether its
+// short sale restriction is in effect, what its LULD collars are and which MWCB level has been breached.
+// A callback receives every transition. It is safe for concurrent use, so it can be read while messages
+// are still being applied. Callbacks are delivered one at a time in the order the messages were applied.
+// A callback can read the tracker but must not call Apply or the On methods.
+//
+// StatusTracker implements Handler so it can be passed to DecodeTo or used with Dispatch.
+type StatusTracker struct {
+	NopHandler
+
+	mu         sync.RWMutex
+	securities map[uint16]SecurityStatus
+	stocks     map[string]uint16
+	market     MarketStatus
+	callback   func(StatusChange)
+
+	// Changes take a ticket while mu is held and are delivered in ticket order
+	tickets   uint64
+	delivered uint64
+	notify    sync.Mutex
+	turn      *sync.Cond
+}
+
+// NewStatusTracker creates a StatusTracker that calls callback with every change. callback may be nil
+func NewStatusTracker(callback func(StatusChange)) *StatusTracker {
+	t := &StatusTracker{
+		securities: make(map[uint16]SecurityStatus),
+		stocks:     make(map[string]uint16),
+		callback:   callback,
+	}
+	t.turn = sync.NewCond(&t.notify)
+
+	return t
+}
+
+// Apply updates the tracker with a single ITCH message. Messages other than the administrative messages are ignored
+func (t *StatusTracker) Apply(msg ItchMessage) {
+	switch m := msg.(type) {
+	case StockTradingAction:
+		t.OnStockTradingAction(m)
+	case RegSho:
+		t.OnRegSho(m)
+	case OperationalHalt:
+		t.OnOperationalHalt(m)
+	case LuldCollar:
+		t.OnLuldCollar(m)
+	case MwcbLevel:
+		t.OnMwcbLevel(m)
+	case MwcbStatus:
+		t.OnMwcbStatus(m)
+	}
+}
+
+func (t *StatusTracker) OnStockTradingAction(m StockTradingAction) {
+	t.updateSecurity(m.StockLocate, m.Stock, m, func(s *SecurityStatus) StatusChangeKind {
+		if s.TradingState == m.TradingState && s.TradingStateReason == m.Reason {
+			return 0
+		}
+
+		s.TradingState = m.TradingState
+		s.TradingStateReason = m.Reason
+		s.TradingStateTime = m.Timestamp
+
+		return STATUS_CHANGE_TRADING_STATE
+	})
+}
+
+func (t *StatusTracker) OnRegSho(m RegSho) {
+	t.updateSecurity(m.StockLocate, m.Stock, m, func(s *SecurityStatus) StatusChangeKind {
+		if s.RegSho == m.Action {
+			return 0
+		}
+
+		s.RegSho = m.Action
+		s.RegShoTime = m.Timestamp
+
+		return STATUS_CHANGE_REG_SHO
+	})
+}
+
+func (t *StatusTracker) OnOperationalHalt(m OperationalHalt) {
+	t.updateSecurity(m.StockLocate, m.Stock, m, func(s *SecurityStatus) StatusChangeKind {
+		halted := s.OperationallyHalted(m.MarketCode)
+
+		// The slice is replaced rather than modified since copies of the status share it
+		switch {
+		case m.HaltAction == HALT_ACTION_HALT && !halted:
+			s.OperationalHalts = append(slices.Clip(s.OperationalHalts), m.MarketCode)
+		case m.HaltAction == HALT_ACTION_LIFTED && halted:
+			s.OperationalHalts = slices.DeleteFunc(slices.Clone(s.OperationalHalts), func(c MarketCode) bool {
+				return c == m.MarketCode
+			})
+		default:
+			return 0
+		}
+
+		s.OperationalHaltTime = m.Timestamp
+
+		return STATUS_CHANGE_OPERATIONAL_HALT
+	})
+}
+
+func (t *StatusTracker) OnLuldCollar(m LuldCollar) {
+	t.updateSecurity(m.StockLocate, m.Stock, m, func(s *SecurityStatus) StatusChangeKind {
+		s.LuldCollar = m
+		return STATUS_CHANGE_LULD_COLLAR
+	})
+}
+
+func (t *StatusTracker) OnMwcbLevel(m MwcbLevel) {
+	t.updateMarket(m.Timestamp, m, func(s *MarketStatus) StatusChangeKind {
+		s.Levels = m
+		return STATUS_CHANGE_MWCB_LEVEL
+	})
+}
+
+func (t *StatusTracker) OnMwcbStatus(m MwcbStatus) {
+	t.updateMarket(m.Timestamp, m, func(s *MarketStatus) StatusChangeKind {
+		if m.BreachedLevel <= s.BreachedLevel {
+			return 0
+		}
+
+		s.BreachedLevel = m.BreachedLevel
+		s.BreachedTime = m.Timestamp
+
+		return STATUS_CHANGE_MWCB_BREACH
+	})
+}
+
+// updateSecurity applies update to the status of a stock and reports the change if update returns its kind.
+// update returns 0 if the message did not change the status
+func (t *StatusTracker) updateSecurity(locate uint16, stock string, msg ItchMessage, update func(s *SecurityStatus) StatusChangeKind) {
+	t.mu.Lock()
+
+	s, ok := t.securities[locate]
+	if !ok {
+		s = SecurityStatus{Stock: stock, StockLocate: locate}
+		t.stocks[stock] = locate
+	}
+
+	kind := update(&s)
+	t.securities[locate] = s
+	market := t.market
+
+	ticket, notify := t.ticket(kind)
+
+	t.mu.Unlock()
+
+	if notify {
+		t.deliver(ticket, StatusChange{Kind: kind, Timestamp: msg.Header().Timestamp, Security: s, Market: market, Message: msg})
+	}
+}
+
+// updateMarket applies update to the market wide status and reports the change if update returns its kind
+func (t *StatusTracker) updateMarket(timestamp time.Duration, msg ItchMessage, update func(s *MarketStatus) StatusChangeKind) {
+	t.mu.Lock()
+
+	kind := update(&t.market)
+	market := t.market
+
+	ticket, notify := t.ticket(kind)
+
+	t.mu.Unlock()
+
+	if notify {
+		t.deliver(ticket, StatusChange{Kind: kind, Timestamp: timestamp, Market: market, Message: msg})
+	}
+}
+
+// ticket reserves the next place in the callback order for a change. It must be called with mu held
+func (t *StatusTracker) ticket(kind StatusChangeKind) (uint64, bool) {
+	if kind == 0 || t.callback == nil {
+		return 0, false
+	}
+
+	ticket := t.tickets
+	t.tickets++
+
+	return ticket, true
+}
+
+// deliver waits until every earlier change has been delivered and then calls the callback. mu is not held,
+// so the callback can read the tracker
+func (t *StatusTracker) deliver(ticket uint64, change StatusChange) {
+	t.notify.Lock()
+	defer t.notify.Unlock()
+
+	for t.delivered != ticket {
+		t.turn.Wait()
+	}
+
+	defer func() {
+		t.delivered++
+		t.turn.Broadcast()
+	}()
+
+	t.callback(change)
+}
+
+// Security returns the status of a stock by its stock locate code. It is false if there have been no
+// administrative messages for the stock
+func (t *StatusTracker) Security(stockLocate uint16) (SecurityStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s, ok := t.securities[stockLocate]
+	return s, ok
+}
+
+// Lookup returns the status of a stock by its symbol
+func (t *StatusTracker) Lookup(stock string) (SecurityStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	locate, ok := t.stocks[stock]
+	if !ok {
+		return SecurityStatus{}, false
+	}
+
+	return t.securities[locate], true
+}
+
+// Tradable reports whether a stock is tradable on Nasdaq, taking both its own status and a market wide
+// circuit breaker halt into account. It is false if there have been no administrative messages for the stock
+func (t *StatusTracker) Tradable(stockLocate uint16) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s, ok := t.securities[stockLocate]
+	return ok && s.Tradable() && !t.market.Halted()
+}
+
+// Market returns the market wide circuit breaker status
+func (t *StatusTracker) Market() MarketStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.market
+}
+
+func (k StatusChangeKind) String() string {
+	switch k {
+	case STATUS_CHANGE_TRADING_STATE:
+		return "Trading State"
+	case STATUS_CHANGE_REG_SHO:
+		return "Reg SHO"
+	case STATUS_CHANGE_OPERATIONAL_HALT:
+		return "Operational Halt"
+	case STATUS_CHANGE_LULD_COLLAR:
+		return "LULD Collar"
+	case STATUS_CHANGE_MWCB_LEVEL:
+		return "MWCB Level"
+	case STATUS_CHANGE_MWCB_BREACH:
+		return "MWCB Breach"
+	}
+
+	return "Unknown Status Change"
+}
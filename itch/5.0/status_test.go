@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/quagmt/udecimal"
+)
+
+func statusMessages() []ItchMessage {
+	return []ItchMessage{
+		StockTradingAction{Stock: "AAPL", StockLocate: 13, Timestamp: 4 * time.Hour, TradingState: STATE_TRADING},
+		StockTradingAction{Stock: "AAPL", StockLocate: 13, Timestamp: 4*time.Hour + time.Second, TradingState: STATE_TRADING},
+		RegSho{Stock: "AAPL", StockLocate: 13, Timestamp: 4 * time.Hour, Action: REGSHO_NO_PRICE_TEST},
+		MwcbLevel{Timestamp: 9 * time.Hour, LevelOne: udecimal.MustParse("2967.72"), LevelTwo: udecimal.MustParse("2775.02"), LevelThree: udecimal.MustParse("2582.32")},
+		StockTradingAction{Stock: "AAPL", StockLocate: 13, Timestamp: 10 * time.Hour, TradingState: STATE_PAUSED, Reason: "LUDP"},
+		LuldCollar{Stock: "AAPL", StockLocate: 13, Timestamp: 10*time.Hour + 5*time.Minute, ReferencePrice: udecimal.MustParse("300"), UpperPrice: udecimal.MustParse("315"), LowerPrice: udecimal.MustParse("285")},
+		StockTradingAction{Stock: "AAPL", StockLocate: 13, Timestamp: 10*time.Hour + 9*time.Minute, TradingState: STATE_QUOTATION, Reason: "LUDP"},
+		StockTradingAction{Stock: "AAPL", StockLocate: 13, Timestamp: 10*time.Hour + 10*time.Minute, TradingState: STATE_TRADING},
+		RegSho{Stock: "AAPL", StockLocate: 13, Timestamp: 11 * time.Hour, Action: REGSHO_INTRADAY_DROP},
+		OperationalHalt{Stock: "AAPL", StockLocate: 13, Timestamp: 12 * time.Hour, MarketCode: MARKET_CODE_BX, HaltAction: HALT_ACTION_HALT},
+		OperationalHalt{Stock: "AAPL", StockLocate: 13, Timestamp: 12 * time.Hour, MarketCode: MARKET_CODE_NASDAQ, HaltAction: HALT_ACTION_HALT},
+		MwcbStatus{Timestamp: 13 * time.Hour, BreachedLevel: BREACHED_LEVEL_1},
+		OperationalHalt{Stock: "AAPL", StockLocate: 13, Timestamp: 13 * time.Hour, MarketCode: MARKET_CODE_NASDAQ, HaltAction: HALT_ACTION_LIFTED},
+		MwcbStatus{Timestamp: 13 * time.Hour, BreachedLevel: BREACHED_LEVEL_1},
+		StockTradingAction{Stock: "MSFT", StockLocate: 14, Timestamp: 14 * time.Hour, TradingState: STATE_HALTED, Reason: "T1"},
+	}
+}
+
+func TestStatusTracker(t *testing.T) {
+	changes := []StatusChangeKind{}
+	times := []time.Duration{}
+
+	tracker := NewStatusTracker(func(c StatusChange) {
+		changes = append(changes, c.Kind)
+		times = append(times, c.Timestamp)
+	})
+
+	// Check the status part way through the day
+	for _, m := range statusMessages()[:5] {
+		tracker.Apply(m)
+	}
+
+	aapl, ok := tracker.Lookup("AAPL")
+	if !ok {
+		t.Fatalf("Lookup(AAPL) not found")
+	}
+	if aapl.Tradable() || !aapl.Paused() || aapl.TradingStateTime != 10*time.Hour || aapl.TradingStateReason != "LUDP" {
+		t.Errorf("AAPL status = %+v, want paused at 10:00", aapl)
+	}
+
+	for _, m := range statusMessages()[5:] {
+		tracker.Apply(m)
+	}
+
+	wantChanges := []StatusChangeKind{
+		STATUS_CHANGE_TRADING_STATE,
+		STATUS_CHANGE_REG_SHO,
+		STATUS_CHANGE_MWCB_LEVEL,
+		STATUS_CHANGE_TRADING_STATE,
+		STATUS_CHANGE_LULD_COLLAR,
+		STATUS_CHANGE_TRADING_STATE,
+		STATUS_CHANGE_TRADING_STATE,
+		STATUS_CHANGE_REG_SHO,
+		STATUS_CHANGE_OPERATIONAL_HALT,
+		STATUS_CHANGE_OPERATIONAL_HALT,
+		STATUS_CHANGE_MWCB_BREACH,
+		STATUS_CHANGE_OPERATIONAL_HALT,
+		STATUS_CHANGE_TRADING_STATE,
+	}
+	if !cmp.Equal(changes, wantChanges) {
+		t.Errorf("%v", cmp.Diff(wantChanges, changes))
+	}
+	if times[0] != 4*time.Hour || times[len(times)-1] != 14*time.Hour {
+		t.Errorf("change times = %v", times)
+	}
+
+	aapl, _ = tracker.Security(13)
+	want := SecurityStatus{
+		Stock:               "AAPL",
+		StockLocate:         13,
+		TradingState:        STATE_TRADING,
+		TradingStateTime:    10*time.Hour + 10*time.Minute,
+		RegSho:              REGSHO_INTRADAY_DROP,
+		RegShoTime:          11 * time.Hour,
+		OperationalHalts:    []MarketCode{MARKET_CODE_BX},
+		OperationalHaltTime: 13 * time.Hour,
+		LuldCollar:          statusMessages()[5].(LuldCollar),
+	}
+	if !cmp.Equal(aapl, want) {
+		t.Errorf("%v", cmp.Diff(want, aapl))
+	}
+	if !aapl.Tradable() || !aapl.ShortSaleRestricted() || !aapl.OperationallyHalted(MARKET_CODE_BX) {
+		t.Errorf("AAPL status = %+v, want tradable with short sale restriction", aapl)
+	}
+
+	if msft, _ := tracker.Lookup("MSFT"); !msft.Halted() || msft.Tradable() {
+		t.Errorf("MSFT status = %+v, want halted", msft)
+	}
+
+	if market := tracker.Market(); market.BreachedLevel != BREACHED_LEVEL_1 || market.BreachedTime != 13*time.Hour || market.Levels.Timestamp != 9*time.Hour {
+		t.Errorf("Market() = %+v", market)
+	}
+
+	if _, ok := tracker.Lookup("TSLA"); ok {
+		t.Errorf("Lookup(TSLA) found a status")
+	}
+}
+
+func TestStatusTracker_DecodeTo(t *testing.T) {
+	tracker := NewStatusTracker(nil)
+
+	if err := DecodeTo(bytes.NewReader(encodeMessages(statusMessages(), true)), Configuration{LengthFieldPrefixed: true}, tracker); err != nil {
+		t.Fatalf("DecodeTo() error = %v", err)
+	}
+
+	if aapl, _ := tracker.Lookup("AAPL"); !aapl.ShortSaleRestricted() || aapl.LuldCollar.UpperPrice.String() != "315" {
+		t.Errorf("AAPL status = %+v", aapl)
+	}
+}
+
+func TestStatusTracker_MarketHalt(t *testing.T) {
+	tracker := NewStatusTracker(nil)
+
+	tracker.Apply(StockTradingAction{Stock: "AAPL", StockLocate: 13, Timestamp: 4 * time.Hour, TradingState: STATE_TRADING})
+	tracker.Apply(MwcbStatus{Timestamp: 13 * time.Hour, BreachedLevel: BREACHED_LEVEL_2})
+
+	if !tracker.Tradable(13) || tracker.Market().Halted() {
+		t.Errorf("Tradable(13) = false after a Level 2 breach, want true")
+	}
+
+	tracker.Apply(MwcbStatus{Timestamp: 14 * time.Hour, BreachedLevel: BREACHED_LEVEL_3})
+
+	if tracker.Tradable(13) || !tracker.Market().Halted() {
+		t.Errorf("Tradable(13) = true after a Level 3 breach, want false")
+	}
+	if aapl, _ := tracker.Security(13); !aapl.Tradable() {
+		t.Errorf("SecurityStatus.Tradable() = false, want true since it ignores the market status")
+	}
+	if tracker.Tradable(14) {
+		t.Errorf("Tradable(14) = true for an unknown stock")
+	}
+}
+
+func TestStatusTracker_CallbackOrder(t *testing.T) {
+	var tracker *StatusTracker
+	var last SecurityStatus
+	count := 0
+
+	tracker = NewStatusTracker(func(c StatusChange) {
+		// A change is only reported when the action differs, so in order delivery never repeats an action
+		if count > 0 && c.Security.RegSho == last.RegSho {
+			t.Errorf("change %d repeats Reg SHO action %v", count, c.Security.RegSho)
+		}
+		last = c.Security
+		count++
+
+		// Reading the tracker from a callback must not deadlock
+		_, _ = tracker.Security(13)
+	})
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				action := REGSHO_NO_PRICE_TEST
+				if (g+i)%2 == 0 {
+					action = REGSHO_INTRADAY_DROP
+				}
+				tracker.Apply(RegSho{Stock: "AAPL", StockLocate: 13, Timestamp: time.Duration(i), Action: action})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if aapl, _ := tracker.Security(13); aapl.RegSho != last.RegSho {
+		t.Errorf("last change has Reg SHO action %v, want %v", last.RegSho, aapl.RegSho)
+	}
+}
@@ -7,15 +7,9 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 )
 
-var (
-	StockMap  = make(map[string]uint16)
-	Directory = make(map[uint16]StockDirectory)
-)
-
 type MarketCategory uint8
 type FinancialStatusIndicator uint8
 type IssueClassification uint8
@@ -128,85 +122,117 @@ const (
 )
 
 type StockDirectory struct {
-	Timestamp                   time.Duration
-	Stock                       string
-	StockLocate                 uint16
-	TrackingNumber              uint16
-	RoundLotSize                uint32
-	IssueSubType                IssueSubType
-	IssueClassification         IssueClassification
-	InverseIndicator            bool
-	RoundLotsOnly               bool
-	Authenticity                Authenticity
-	EtpLeverageFactor           uint32
-	ShortSaleThresholdIndicator string
-	IpoFlag                     string
-	LuldReferencePriceTier      string
-	EtpFlag                     string
-	MarketCategory              MarketCategory
-	FinancialStatusIndicator    FinancialStatusIndicator
+	Timestamp                   time.Duration            `json:"timestamp"`
+	Stock                       string                   `json:"stock"`
+	StockLocate                 uint16                   `json:"stockLocate"`
+	TrackingNumber              uint16                   `json:"trackingNumber"`
+	RoundLotSize                uint32                   `json:"roundLotSize"`
+	IssueSubType                IssueSubType             `json:"issueSubType"`
+	IssueClassification         IssueClassification      `json:"issueClassification"`
+	InverseIndicator            bool                     `json:"inverseIndicator"`
+	RoundLotsOnly               bool                     `json:"roundLotsOnly"`
+	Authenticity                Authenticity             `json:"authenticity"`
+	EtpLeverageFactor           uint32                   `json:"etpLeverageFactor"`
+	ShortSaleThresholdIndicator string                   `json:"shortSaleThresholdIndicator"`
+	IpoFlag                     string                   `json:"ipoFlag"`
+	LuldReferencePriceTier      string                   `json:"luldReferencePriceTier"`
+	EtpFlag                     string                   `json:"etpFlag"`
+	MarketCategory              MarketCategory           `json:"marketCategory"`
+	FinancialStatusIndicator    FinancialStatusIndicator `json:"financialStatusIndicator"`
 }
 
 func (s StockDirectory) Type() uint8 {
 	return MESSAGE_STOCK_DIRECTORY
 }
 
-func (s StockDirectory) Bytes() []byte {
-	data := make([]byte, stockDirectorySize)
-
-	data[0] = MESSAGE_STOCK_DIRECTORY
-	binary.BigEndian.PutUint16(data[1:3], s.StockLocate)
-
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(s.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], s.TrackingNumber)
+func (s StockDirectory) Header() Header {
+	return Header{Type: MESSAGE_STOCK_DIRECTORY, StockLocate: s.StockLocate, TrackingNumber: s.TrackingNumber, Timestamp: s.Timestamp}
+}
 
-	copy(data[11:19], []byte(fmt.Sprintf("%-8s", s.Stock)))
+func (s StockDirectory) Symbol() Symbol {
+	return NewSymbol(s.Stock)
+}
 
-	data[19] = byte(s.MarketCategory)
-	data[20] = byte(s.FinancialStatusIndicator)
+func (s StockDirectory) Bytes() []byte {
+	return s.appendBinary(make([]byte, 0, stockDirectorySize))
+}
 
-	binary.BigEndian.PutUint32(data[21:25], s.RoundLotSize)
+func (s StockDirectory) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(make([]byte, 0, stockDirectorySize))
+}
 
-	if s.RoundLotsOnly {
-		data[25] = 'Y'
-	} else {
-		data[25] = 'N'
+func (s StockDirectory) AppendBinary(dst []byte) ([]byte, error) {
+	if err := s.validate(); err != nil {
+		return dst, err
 	}
 
-	data[26] = byte(s.IssueClassification)
-	underlying := string(s.IssueSubType)
-	copy(data[27:29], []byte(fmt.Sprintf("%-2s", underlying)))
-
-	data[29] = byte(s.Authenticity)
+	return s.appendBinary(dst), nil
+}
 
-	// TODO: Make types
-	data[30] = []byte(s.ShortSaleThresholdIndicator)[0]
-	data[31] = []byte(s.IpoFlag)[0]
-	data[32] = []byte(s.LuldReferencePriceTier)[0]
-	data[33] = []byte(s.EtpFlag)[0]
+func (s StockDirectory) MarshalJSON() ([]byte, error) {
+	type message StockDirectory
+	return marshalMessageJSON(MESSAGE_STOCK_DIRECTORY, message(s))
+}
 
-	binary.BigEndian.PutUint32(data[34:38], s.EtpLeverageFactor)
+func (s *StockDirectory) UnmarshalJSON(data []byte) error {
+	type message StockDirectory
+	return unmarshalMessageJSON(data, MESSAGE_STOCK_DIRECTORY, (*message)(s))
+}
 
-	if s.InverseIndicator {
-		data[38] = 'Y'
-	} else {
-		data[38] = 'N'
-	}
+func (s StockDirectory) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_STOCK_DIRECTORY, s.StockLocate, s.TrackingNumber, s.Timestamp)
+	dst = appendAlpha(dst, s.Stock, 8)
+	dst = append(dst, byte(s.MarketCategory), byte(s.FinancialStatusIndicator))
+	dst = binary.BigEndian.AppendUint32(dst, s.RoundLotSize)
+	dst = appendBool(dst, s.RoundLotsOnly)
+	dst = append(dst, byte(s.IssueClassification))
+	dst = appendAlpha(dst, string(s.IssueSubType), 2)
+	dst = append(dst, byte(s.Authenticity))
+	dst = appendAlpha(dst, s.ShortSaleThresholdIndicator, 1)
+	dst = appendAlpha(dst, s.IpoFlag, 1)
+	dst = appendAlpha(dst, s.LuldReferencePriceTier, 1)
+	dst = appendAlpha(dst, s.EtpFlag, 1)
+	dst = binary.BigEndian.AppendUint32(dst, s.EtpLeverageFactor)
+	dst = appendBool(dst, s.InverseIndicator)
+
+	return dst
+}
 
-	return data
+func (s StockDirectory) validate() error {
+	v := validator{messageType: MESSAGE_STOCK_DIRECTORY}
+	v.timestamp("Timestamp", s.Timestamp)
+	v.alpha("Stock", s.Stock, 8)
+	v.enum("MarketCategory", s.MarketCategory.valid(), uint8(s.MarketCategory))
+	v.enum("FinancialStatusIndicator", s.FinancialStatusIndicator.valid(), uint8(s.FinancialStatusIndicator))
+	v.enum("IssueClassification", s.IssueClassification.valid(), uint8(s.IssueClassification))
+	v.alpha("IssueSubType", string(s.IssueSubType), 2)
+	v.enum("Authenticity", s.Authenticity.valid(), uint8(s.Authenticity))
+	v.char("ShortSaleThresholdIndicator", s.ShortSaleThresholdIndicator, "YN ")
+	v.char("IpoFlag", s.IpoFlag, "YN ")
+	v.char("LuldReferencePriceTier", s.LuldReferencePriceTier, "12 ")
+	v.char("EtpFlag", s.EtpFlag, "YN ")
+
+	return v.err
 }
 
 func ParseStockDirectory(data []byte) (StockDirectory, error) {
+	var s StockDirectory
+	err := s.UnmarshalBinary(data)
+	return s, err
+}
+
+func (s *StockDirectory) UnmarshalBinary(data []byte) error {
+	return s.decode(data, nil)
+}
+
+func (s *StockDirectory) decode(data []byte, symbols symbolTable) error {
 	if len(data) != stockDirectorySize {
-		return StockDirectory{}, NewInvalidPacketSize(stockDirectorySize, len(data))
+		return NewInvalidPacketSize(stockDirectorySize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	roundLotsOnly := false
 	if data[25] == 'Y' {
@@ -218,33 +244,27 @@ func ParseStockDirectory(data []byte) (StockDirectory, error) {
 		inverseIndicator = true
 	}
 
-	stock := strings.TrimSpace(string(data[11:19]))
-
-	StockMap[stock] = locate
-
-	sd := StockDirectory{
+	*s = StockDirectory{
 		StockLocate:                 locate,
 		TrackingNumber:              tracking,
-		Timestamp:                   time.Duration(t),
-		Stock:                       stock,
+		Timestamp:                   timestamp,
+		Stock:                       symbols.get(data[11:19]),
 		MarketCategory:              MarketCategory(data[19]),
 		FinancialStatusIndicator:    FinancialStatusIndicator(data[20]),
 		RoundLotSize:                binary.BigEndian.Uint32(data[21:25]),
 		RoundLotsOnly:               roundLotsOnly,
 		IssueClassification:         IssueClassification(data[26]),
-		IssueSubType:                IssueSubType(strings.TrimSpace(string(data[27:29]))),
+		IssueSubType:                IssueSubType(symbols.get(data[27:29])),
 		Authenticity:                Authenticity(data[29]),
-		ShortSaleThresholdIndicator: string(data[30]),
-		IpoFlag:                     string(data[31]),
-		LuldReferencePriceTier:      string(data[32]),
-		EtpFlag:                     string(data[33]),
+		ShortSaleThresholdIndicator: string(data[30:31]),
+		IpoFlag:                     string(data[31:32]),
+		LuldReferencePriceTier:      string(data[32:33]),
+		EtpFlag:                     string(data[33:34]),
 		EtpLeverageFactor:           binary.BigEndian.Uint32(data[34:38]),
 		InverseIndicator:            inverseIndicator,
 	}
 
-	Directory[locate] = sd
-
-	return sd, nil
+	return nil
 }
 
 func (e StockDirectory) String() string {
@@ -297,6 +317,31 @@ func (c MarketCategory) String() string {
 	return "Unknown Market Category"
 }
 
+func (c MarketCategory) valid() bool {
+	switch c {
+	case MKTCTG_NASDAQ_GLOBAL_SELECT,
+		MKTCTG_NASDAQ_GLOBAL,
+		MKTCTG_NASDAQ_CAPITAL,
+		MKTCTG_NYSE,
+		MKTCTG_NYSE_AMERICAN,
+		MKTCTG_NYSE_ARCA,
+		MKTCTG_BATS_Z,
+		MKTCTG_INVESTORS_EXCHANGE,
+		MKTCTG_NOT_AVAILABLE:
+		return true
+	}
+
+	return false
+}
+
+func (c MarketCategory) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(c)), c.String())
+}
+
+func (c *MarketCategory) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(c))
+}
+
 func (i FinancialStatusIndicator) String() string {
 	switch i {
 	case FSI_DEFICIENT:
@@ -326,6 +371,33 @@ func (i FinancialStatusIndicator) String() string {
 	return "Unknown Financial Status Indicator"
 }
 
+func (i FinancialStatusIndicator) valid() bool {
+	switch i {
+	case FSI_DEFICIENT,
+		FSI_DELINQUENT,
+		FSI_BANKRUPT,
+		FSI_SUSPENDED,
+		FSI_DEFICIENT_AND_BANKRUPT,
+		FSI_DEFICIENT_AND_DELINQUENT,
+		FSI_DELINQUENT_AND_BANKRUPT,
+		FSI_DEFICIENT_DELINQUENT_BANKRUPT,
+		FSI_CREATIONS_REDEMPTIONS_SUSPENDED,
+		FSI_NORMAL,
+		FSI_NOT_AVAILABLE:
+		return true
+	}
+
+	return false
+}
+
+func (i FinancialStatusIndicator) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(i)), i.String())
+}
+
+func (i *FinancialStatusIndicator) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(i))
+}
+
 func (c IssueClassification) String() string {
 	switch c {
 	case IC_AMERICAN_DEPOSITORY_SHARE:
@@ -365,6 +437,38 @@ func (c IssueClassification) String() string {
 	return "Unknown Issue Classification"
 }
 
+func (c IssueClassification) valid() bool {
+	switch c {
+	case IC_AMERICAN_DEPOSITORY_SHARE,
+		IC_BOND,
+		IC_COMMON_STOCK,
+		IC_DEPOSITORY_RECEIPT,
+		IC_144A,
+		IC_LIMITED_PARTNERSHIP,
+		IC_NOTES,
+		IC_ORDINARY_SHARE,
+		IC_PREFERRED_STOCK,
+		IC_OTHER_SECURITIES,
+		IC_RIGHT,
+		IC_SHARES_BENEFICIAL_INTEREST,
+		IC_CONVERTIBLE_DEBENTURE,
+		IC_UNIT,
+		IC_UNITS_BENIF,
+		IC_WARRANT:
+		return true
+	}
+
+	return false
+}
+
+func (c IssueClassification) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(c)), c.String())
+}
+
+func (c *IssueClassification) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(c))
+}
+
 func (i IssueSubType) String() string {
 	switch i {
 	case ICS_PREFERRED_TRUST_SECURITIES:
@@ -487,6 +591,16 @@ func (i IssueSubType) String() string {
 	return "Unknown Issue-Sub Type"
 }
 
+func (i IssueSubType) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(i), i.String())
+}
+
+func (i *IssueSubType) UnmarshalJSON(data []byte) error {
+	code, err := unmarshalEnumJSON(data)
+	*i = IssueSubType(code)
+	return err
+}
+
 func (a Authenticity) String() string {
 	switch a {
 	case AUTHENTICITY_LIVE:
@@ -497,3 +611,20 @@ func (a Authenticity) String() string {
 
 	return "Unknown Authenticity"
 }
+
+func (a Authenticity) valid() bool {
+	switch a {
+	case AUTHENTICITY_LIVE, AUTHENTICITY_TEST:
+		return true
+	}
+
+	return false
+}
+
+func (a Authenticity) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(a)), a.String())
+}
+
+func (a *Authenticity) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(a))
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import "strings"
+
+// maxSymbols bounds the size of a symbolTable. A full day of TotalView has under 10,000 symbols so the
+// limit is only reached with corrupt input
+const maxSymbols = 1 << 16
+
+// Symbol is a stock symbol as it appears in ITCH: eight bytes, left justified and padded with spaces. Unlike
+// a string it has a fixed size, so it can be copied, compared and used as a map key without allocating.
+// Messages with a Stock field return it as a Symbol from their Symbol method
+type Symbol [8]byte
+
+// NewSymbol returns s as a Symbol. s is truncated to 8 bytes
+func NewSymbol(s string) Symbol {
+	var sym Symbol
+
+	n := copy(sym[:], s)
+	for i := n; i < len(sym); i++ {
+		sym[i] = ' '
+	}
+
+	return sym
+}
+
+// String returns the symbol with the space padding removed
+func (s Symbol) String() string {
+	return strings.TrimRight(string(s[:]), " ")
+}
+
+// symbolKey is a fixed size copy of an alpha field, such as a Stock symbol, used to look up the string
+// previously allocated for the same bytes
+type symbolKey struct {
+	data Symbol
+	n    uint8
+}
+
+// symbolTable interns the alpha fields of decoded messages so that decoding the same symbol again does
+// not allocate a new string. Once maxSymbols different fields have been seen new ones are still decoded
+// but allocate every time. A nil symbolTable allocates a new string every time
+type symbolTable map[symbolKey]string
+
+func newSymbolTable() symbolTable {
+	return make(symbolTable)
+}
+
+// get returns data as a string with the space padding removed. data must be at most 8 bytes
+func (t symbolTable) get(data []byte) string {
+	if t == nil {
+		return strings.TrimSpace(string(data))
+	}
+
+	key := symbolKey{n: uint8(len(data))}
+	copy(key.data[:], data)
+
+	if s, ok := t[key]; ok {
+		return s
+	}
+
+	s := strings.TrimSpace(string(data))
+	if len(t) < maxSymbols {
+		t[key] = s
+	}
+
+	return s
+}
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"testing"
+)
+
+func TestNewSymbol(t *testing.T) {
+	tests := []struct {
+		stock string
+		want  Symbol
+	}{
+		{"AAPL", Symbol{'A', 'A', 'P', 'L', ' ', ' ', ' ', ' '}},
+		{"BRK A", Symbol{'B', 'R', 'K', ' ', 'A', ' ', ' ', ' '}},
+		{"ABCDEFGH", Symbol{'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H'}},
+		{"ABCDEFGHIJ", Symbol{'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H'}},
+		{"", Symbol{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}},
+	}
+
+	for _, tt := range tests {
+		got := NewSymbol(tt.stock)
+		if got != tt.want {
+			t.Errorf("NewSymbol(%q) = %q, want %q", tt.stock, got[:], tt.want[:])
+		}
+		if got.String() != tt.stock[:min(len(tt.stock), 8)] {
+			t.Errorf("NewSymbol(%q).String() = %q", tt.stock, got.String())
+		}
+	}
+}
+
+func TestItchMessage_Symbol(t *testing.T) {
+	for _, m := range allMessages() {
+		s, ok := m.(interface{ Symbol() Symbol })
+		if !ok {
+			continue
+		}
+
+		// The Stock field is the only 8 byte alpha field in a message, so its encoded bytes contain the symbol
+		data := m.Bytes()
+		sym := s.Symbol()
+		found := false
+		for i := 0; i+len(sym) <= len(data); i++ {
+			if Symbol(data[i:i+len(sym)]) == sym {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%T.Symbol() = %q not found in encoded message", m, sym[:])
+		}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = s.Symbol()
+		})
+		if allocs != 0 {
+			t.Errorf("%T.Symbol() allocations = %v, want 0", m, allocs)
+		}
+	}
+}
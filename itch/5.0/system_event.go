@@ -26,49 +26,86 @@ const (
 )
 
 type SystemEvent struct {
-	Timestamp      time.Duration
-	StockLocate    uint16
-	TrackingNumber uint16
-	EventCode      EventCode
+	Timestamp      time.Duration `json:"timestamp"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	EventCode      EventCode     `json:"eventCode"`
 }
 
 func (e SystemEvent) Type() uint8 {
 	return MESSAGE_SYSTEM_EVENT
 }
 
+func (e SystemEvent) Header() Header {
+	return Header{Type: MESSAGE_SYSTEM_EVENT, StockLocate: e.StockLocate, TrackingNumber: e.TrackingNumber, Timestamp: e.Timestamp}
+}
+
 func (e SystemEvent) Bytes() []byte {
-	data := make([]byte, systemEventSize)
+	return e.appendBinary(make([]byte, 0, systemEventSize))
+}
+
+func (e SystemEvent) MarshalBinary() ([]byte, error) {
+	return e.AppendBinary(make([]byte, 0, systemEventSize))
+}
 
-	data[0] = MESSAGE_SYSTEM_EVENT
-	binary.BigEndian.PutUint16(data[1:3], 0)
+func (e SystemEvent) AppendBinary(dst []byte) ([]byte, error) {
+	if err := e.validate(); err != nil {
+		return dst, err
+	}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(e.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], e.TrackingNumber)
+	return e.appendBinary(dst), nil
+}
 
-	data[11] = byte(e.EventCode)
+func (e SystemEvent) MarshalJSON() ([]byte, error) {
+	type message SystemEvent
+	return marshalMessageJSON(MESSAGE_SYSTEM_EVENT, message(e))
+}
 
-	return data
+func (e *SystemEvent) UnmarshalJSON(data []byte) error {
+	type message SystemEvent
+	return unmarshalMessageJSON(data, MESSAGE_SYSTEM_EVENT, (*message)(e))
+}
+
+func (e SystemEvent) appendBinary(dst []byte) []byte {
+	// Stock Locate is always 0 for System Event messages
+	dst = appendHeader(dst, MESSAGE_SYSTEM_EVENT, 0, e.TrackingNumber, e.Timestamp)
+	dst = append(dst, byte(e.EventCode))
+
+	return dst
+}
+
+func (e SystemEvent) validate() error {
+	v := validator{messageType: MESSAGE_SYSTEM_EVENT}
+	v.timestamp("Timestamp", e.Timestamp)
+	v.enum("EventCode", e.EventCode.valid(), uint8(e.EventCode))
+
+	return v.err
 }
 
 func ParseSystemEvent(data []byte) (SystemEvent, error) {
+	var e SystemEvent
+	err := e.UnmarshalBinary(data)
+	return e, err
+}
+
+func (e *SystemEvent) UnmarshalBinary(data []byte) error {
 	if len(data) != systemEventSize {
-		return SystemEvent{}, NewInvalidPacketSize(systemEventSize, len(data))
+		return NewInvalidPacketSize(systemEventSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 	event := EventCode(data[11])
 
-	return SystemEvent{
+	*e = SystemEvent{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		EventCode:      event,
-	}, nil
+	}
+
+	return nil
 }
 
 func MakeSystemEvent(timestamp time.Duration, trackingNumber uint16, eventCode EventCode) SystemEvent {
@@ -107,3 +144,25 @@ func (e EventCode) String() string {
 
 	return "Unknown EventCode"
 }
+
+func (e EventCode) valid() bool {
+	switch e {
+	case EVENT_START_MESSAGES,
+		EVENT_START_HOURS,
+		EVENT_START_MARKET,
+		EVENT_END_MARKET,
+		EVENT_END_HOURS,
+		EVENT_END_MESSAGES:
+		return true
+	}
+
+	return false
+}
+
+func (e EventCode) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(e)), e.String())
+}
+
+func (e *EventCode) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(e))
+}
@@ -0,0 +1,15 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import "time"
+
+// parseTimestamp reads the 6 byte Timestamp field, nanoseconds since midnight, without modifying data
+func parseTimestamp(data []byte) time.Duration {
+	_ = data[5] // bounds check hint to compiler
+
+	return time.Duration(uint64(data[0])<<40 | uint64(data[1])<<32 | uint64(data[2])<<24 |
+		uint64(data[3])<<16 | uint64(data[4])<<8 | uint64(data[5]))
+}
@@ -11,48 +11,83 @@ import (
 )
 
 type TradeBroken struct {
-	StockLocate    uint16
-	TrackingNumber uint16
-	Timestamp      time.Duration
-	MatchNumber    uint64
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	Timestamp      time.Duration `json:"timestamp"`
+	MatchNumber    uint64        `json:"matchNumber"`
 }
 
 func (t TradeBroken) Type() uint8 {
 	return MESSAGE_TRADE_BROKEN
 }
 
+func (t TradeBroken) Header() Header {
+	return Header{Type: MESSAGE_TRADE_BROKEN, StockLocate: t.StockLocate, TrackingNumber: t.TrackingNumber, Timestamp: t.Timestamp}
+}
+
 func (t TradeBroken) Bytes() []byte {
-	data := make([]byte, tradeBrokenSize)
+	return t.appendBinary(make([]byte, 0, tradeBrokenSize))
+}
+
+func (t TradeBroken) MarshalBinary() ([]byte, error) {
+	return t.AppendBinary(make([]byte, 0, tradeBrokenSize))
+}
+
+func (t TradeBroken) AppendBinary(dst []byte) ([]byte, error) {
+	if err := t.validate(); err != nil {
+		return dst, err
+	}
+
+	return t.appendBinary(dst), nil
+}
 
-	data[0] = MESSAGE_TRADE_BROKEN
-	binary.BigEndian.PutUint16(data[1:3], t.StockLocate)
+func (t TradeBroken) MarshalJSON() ([]byte, error) {
+	type message TradeBroken
+	return marshalMessageJSON(MESSAGE_TRADE_BROKEN, message(t))
+}
+
+func (t *TradeBroken) UnmarshalJSON(data []byte) error {
+	type message TradeBroken
+	return unmarshalMessageJSON(data, MESSAGE_TRADE_BROKEN, (*message)(t))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(t.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], t.TrackingNumber)
+func (t TradeBroken) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_TRADE_BROKEN, t.StockLocate, t.TrackingNumber, t.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, t.MatchNumber)
+
+	return dst
+}
 
-	binary.BigEndian.PutUint64(data[11:], t.MatchNumber)
+func (t TradeBroken) validate() error {
+	v := validator{messageType: MESSAGE_TRADE_BROKEN}
+	v.timestamp("Timestamp", t.Timestamp)
 
-	return data
+	return v.err
 }
 
 func ParseTradeBroken(data []byte) (TradeBroken, error) {
+	var t TradeBroken
+	err := t.UnmarshalBinary(data)
+	return t, err
+}
+
+func (t *TradeBroken) UnmarshalBinary(data []byte) error {
 	if len(data) != tradeBrokenSize {
-		return TradeBroken{}, NewInvalidPacketSize(tradeBrokenSize, len(data))
+		return NewInvalidPacketSize(tradeBrokenSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return TradeBroken{
+	*t = TradeBroken{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		MatchNumber:    binary.BigEndian.Uint64(data[11:]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (o TradeBroken) String() string {
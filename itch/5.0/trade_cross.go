@@ -7,7 +7,6 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/quagmt/udecimal"
@@ -23,67 +22,108 @@ const (
 )
 
 type TradeCross struct {
-	Stock          string
-	Timestamp      time.Duration
-	MatchNumber    uint64
-	Shares         uint64
-	CrossPrice     udecimal.Decimal // Price (4)
-	StockLocate    uint16
-	TrackingNumber uint16
-	CrossType      CrossType
+	Stock          string           `json:"stock"`
+	Timestamp      time.Duration    `json:"timestamp"`
+	MatchNumber    uint64           `json:"matchNumber"`
+	Shares         uint64           `json:"shares"`
+	CrossPrice     udecimal.Decimal `json:"crossPrice"` // Price (4)
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	CrossType      CrossType        `json:"crossType"`
 }
 
 func (t TradeCross) Type() uint8 {
 	return MESSAGE_TRADE_CROSS
 }
 
+func (t TradeCross) Header() Header {
+	return Header{Type: MESSAGE_TRADE_CROSS, StockLocate: t.StockLocate, TrackingNumber: t.TrackingNumber, Timestamp: t.Timestamp}
+}
+
+func (t TradeCross) Symbol() Symbol {
+	return NewSymbol(t.Stock)
+}
+
 func (t TradeCross) Bytes() []byte {
-	data := make([]byte, tradeCrossSize)
+	return t.appendBinary(make([]byte, 0, tradeCrossSize))
+}
+
+func (t TradeCross) MarshalBinary() ([]byte, error) {
+	return t.AppendBinary(make([]byte, 0, tradeCrossSize))
+}
 
-	data[0] = MESSAGE_TRADE_CROSS
-	binary.BigEndian.PutUint16(data[1:3], t.StockLocate)
+func (t TradeCross) AppendBinary(dst []byte) ([]byte, error) {
+	if err := t.validate(); err != nil {
+		return dst, err
+	}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(t.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], t.TrackingNumber)
+	return t.appendBinary(dst), nil
+}
 
-	binary.BigEndian.PutUint64(data[11:19], t.Shares)
+func (t TradeCross) MarshalJSON() ([]byte, error) {
+	type message TradeCross
+	return marshalMessageJSON(MESSAGE_TRADE_CROSS, message(t))
+}
 
-	copy(data[19:27], []byte(fmt.Sprintf("%-8s", t.Stock)))
+func (t *TradeCross) UnmarshalJSON(data []byte) error {
+	type message TradeCross
+	return unmarshalMessageJSON(data, MESSAGE_TRADE_CROSS, (*message)(t))
+}
 
-	price, _ := priceToBytes(t.CrossPrice, 4)
-	copy(data[27:31], price)
+func (t TradeCross) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_TRADE_CROSS, t.StockLocate, t.TrackingNumber, t.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, t.Shares)
+	dst = appendAlpha(dst, t.Stock, 8)
+	dst = appendPrice(dst, t.CrossPrice, 4)
+	dst = binary.BigEndian.AppendUint64(dst, t.MatchNumber)
+	dst = append(dst, byte(t.CrossType))
 
-	binary.BigEndian.PutUint64(data[31:39], t.MatchNumber)
+	return dst
+}
 
-	data[39] = byte(t.CrossType)
+func (t TradeCross) validate() error {
+	v := validator{messageType: MESSAGE_TRADE_CROSS}
+	v.timestamp("Timestamp", t.Timestamp)
+	v.alpha("Stock", t.Stock, 8)
+	v.price("CrossPrice", t.CrossPrice, 4)
+	v.enum("CrossType", t.CrossType.valid(), uint8(t.CrossType))
 
-	return data
+	return v.err
 }
 
 func ParseTradeCross(data []byte) (TradeCross, error) {
+	var t TradeCross
+	err := t.UnmarshalBinary(data)
+	return t, err
+}
+
+func (t *TradeCross) UnmarshalBinary(data []byte) error {
+	return t.decode(data, nil)
+}
+
+func (t *TradeCross) decode(data []byte, symbols symbolTable) error {
 	if len(data) != tradeCrossSize {
-		return TradeCross{}, NewInvalidPacketSize(tradeCrossSize, len(data))
+		return NewInvalidPacketSize(tradeCrossSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	price, _ := bytesToPrice(data[27:31], 4)
 
-	return TradeCross{
+	*t = TradeCross{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Shares:         binary.BigEndian.Uint64(data[11:19]),
-		Stock:          strings.TrimSpace(string(data[19:27])),
+		Stock:          symbols.get(data[19:27]),
 		CrossPrice:     price,
 		MatchNumber:    binary.BigEndian.Uint64(data[31:39]),
 		CrossType:      CrossType(data[39]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (o TradeCross) String() string {
@@ -114,3 +154,23 @@ func (c CrossType) String() string {
 
 	return "Unknown CrossType"
 }
+
+func (c CrossType) valid() bool {
+	switch c {
+	case CROSS_TYPE_NASDAQ_OPEN,
+		CROSS_TYPE_NASDAQ_CLOSE,
+		CROSS_TYPE_IPO_HALTED,
+		CROSS_TYPE_EXTENDED_TRADING_CLOSE:
+		return true
+	}
+
+	return false
+}
+
+func (c CrossType) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(c)), c.String())
+}
+
+func (c *CrossType) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(c))
+}
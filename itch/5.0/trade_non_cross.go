@@ -7,78 +7,117 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/quagmt/udecimal"
 )
 
 type TradeNonCross struct {
-	Stock          string
-	Timestamp      time.Duration
-	Reference      uint64
-	MatchNumber    uint64
-	Shares         uint32
-	Price          udecimal.Decimal // Price (4)
-	StockLocate    uint16
-	TrackingNumber uint16
-	OrderIndicator OrderIndicator
+	Stock          string           `json:"stock"`
+	Timestamp      time.Duration    `json:"timestamp"`
+	Reference      uint64           `json:"reference"`
+	MatchNumber    uint64           `json:"matchNumber"`
+	Shares         uint32           `json:"shares"`
+	Price          udecimal.Decimal `json:"price"` // Price (4)
+	StockLocate    uint16           `json:"stockLocate"`
+	TrackingNumber uint16           `json:"trackingNumber"`
+	OrderIndicator OrderIndicator   `json:"orderIndicator"`
 }
 
 func (t TradeNonCross) Type() uint8 {
 	return MESSAGE_TRADE_NON_CROSS
 }
 
+func (t TradeNonCross) Header() Header {
+	return Header{Type: MESSAGE_TRADE_NON_CROSS, StockLocate: t.StockLocate, TrackingNumber: t.TrackingNumber, Timestamp: t.Timestamp}
+}
+
+func (t TradeNonCross) Symbol() Symbol {
+	return NewSymbol(t.Stock)
+}
+
 func (t TradeNonCross) Bytes() []byte {
-	data := make([]byte, tradeNonCrossSize)
+	return t.appendBinary(make([]byte, 0, tradeNonCrossSize))
+}
 
-	data[0] = MESSAGE_TRADE_NON_CROSS
-	binary.BigEndian.PutUint16(data[1:3], t.StockLocate)
+func (t TradeNonCross) MarshalBinary() ([]byte, error) {
+	return t.AppendBinary(make([]byte, 0, tradeNonCrossSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(t.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], t.TrackingNumber)
+func (t TradeNonCross) AppendBinary(dst []byte) ([]byte, error) {
+	if err := t.validate(); err != nil {
+		return dst, err
+	}
 
-	binary.BigEndian.PutUint64(data[11:19], t.Reference)
+	return t.appendBinary(dst), nil
+}
 
-	data[19] = byte(t.OrderIndicator)
+func (t TradeNonCross) MarshalJSON() ([]byte, error) {
+	type message TradeNonCross
+	return marshalMessageJSON(MESSAGE_TRADE_NON_CROSS, message(t))
+}
 
-	binary.BigEndian.PutUint32(data[20:24], t.Shares)
+func (t *TradeNonCross) UnmarshalJSON(data []byte) error {
+	type message TradeNonCross
+	return unmarshalMessageJSON(data, MESSAGE_TRADE_NON_CROSS, (*message)(t))
+}
 
-	copy(data[24:32], []byte(fmt.Sprintf("%-8s", t.Stock)))
+func (t TradeNonCross) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_TRADE_NON_CROSS, t.StockLocate, t.TrackingNumber, t.Timestamp)
+	dst = binary.BigEndian.AppendUint64(dst, t.Reference)
+	dst = append(dst, byte(t.OrderIndicator))
+	dst = binary.BigEndian.AppendUint32(dst, t.Shares)
+	dst = appendAlpha(dst, t.Stock, 8)
+	dst = appendPrice(dst, t.Price, 4)
+	dst = binary.BigEndian.AppendUint64(dst, t.MatchNumber)
 
-	price, _ := priceToBytes(t.Price, 4)
-	copy(data[32:36], price)
+	return dst
+}
 
-	binary.BigEndian.PutUint64(data[36:], t.MatchNumber)
+func (t TradeNonCross) validate() error {
+	v := validator{messageType: MESSAGE_TRADE_NON_CROSS}
+	v.timestamp("Timestamp", t.Timestamp)
+	v.enum("OrderIndicator", t.OrderIndicator.valid(), uint8(t.OrderIndicator))
+	v.alpha("Stock", t.Stock, 8)
+	v.price("Price", t.Price, 4)
 
-	return data
+	return v.err
 }
 
 func ParseTradeNonCross(data []byte) (TradeNonCross, error) {
+	var t TradeNonCross
+	err := t.UnmarshalBinary(data)
+	return t, err
+}
+
+func (t *TradeNonCross) UnmarshalBinary(data []byte) error {
+	return t.decode(data, nil)
+}
+
+func (t *TradeNonCross) decode(data []byte, symbols symbolTable) error {
 	if len(data) != tradeNonCrossSize {
-		return TradeNonCross{}, NewInvalidPacketSize(tradeNonCrossSize, len(data))
+		return NewInvalidPacketSize(tradeNonCrossSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
 	price, _ := bytesToPrice(data[32:36], 4)
 
-	return TradeNonCross{
+	*t = TradeNonCross{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
+		Timestamp:      timestamp,
 		Reference:      binary.BigEndian.Uint64(data[11:19]),
 		OrderIndicator: OrderIndicator(data[19]),
 		Shares:         binary.BigEndian.Uint32(data[20:24]),
-		Stock:          strings.TrimSpace(string(data[24:32])),
+		Stock:          symbols.get(data[24:32]),
 		Price:          price,
 		MatchNumber:    binary.BigEndian.Uint64(data[36:]),
-	}, nil
+	}
+
+	return nil
 }
 
 func (o TradeNonCross) String() string {
@@ -7,7 +7,6 @@ package itch
 import (
 	"encoding/binary"
 	"fmt"
-	"strings"
 	"time"
 )
 
@@ -21,59 +20,102 @@ const (
 )
 
 type StockTradingAction struct {
-	Stock          string
-	Reason         string
-	Timestamp      time.Duration
-	StockLocate    uint16
-	TrackingNumber uint16
-	TradingState   TradingState
-	Reserved       uint8
+	Stock          string        `json:"stock"`
+	Reason         string        `json:"reason"`
+	Timestamp      time.Duration `json:"timestamp"`
+	StockLocate    uint16        `json:"stockLocate"`
+	TrackingNumber uint16        `json:"trackingNumber"`
+	TradingState   TradingState  `json:"tradingState"`
+	Reserved       uint8         `json:"reserved"`
 }
 
 func (t StockTradingAction) Type() uint8 {
 	return MESSAGE_STOCK_TRADING_ACTION
 }
 
+func (t StockTradingAction) Header() Header {
+	return Header{Type: MESSAGE_STOCK_TRADING_ACTION, StockLocate: t.StockLocate, TrackingNumber: t.TrackingNumber, Timestamp: t.Timestamp}
+}
+
+func (t StockTradingAction) Symbol() Symbol {
+	return NewSymbol(t.Stock)
+}
+
 func (t StockTradingAction) Bytes() []byte {
-	data := make([]byte, stockTradingActionSize)
+	return t.appendBinary(make([]byte, 0, stockTradingActionSize))
+}
 
-	data[0] = MESSAGE_STOCK_TRADING_ACTION
-	binary.BigEndian.PutUint16(data[1:3], t.StockLocate)
+func (t StockTradingAction) MarshalBinary() ([]byte, error) {
+	return t.AppendBinary(make([]byte, 0, stockTradingActionSize))
+}
 
-	// Order of these fields are important. We write timestamp to 3:11 first to let us write a uint64, then overwrite 3:5 with tracking number
-	binary.BigEndian.PutUint64(data[3:11], uint64(t.Timestamp.Nanoseconds()))
-	binary.BigEndian.PutUint16(data[3:5], t.TrackingNumber)
+func (t StockTradingAction) AppendBinary(dst []byte) ([]byte, error) {
+	if err := t.validate(); err != nil {
+		return dst, err
+	}
 
-	copy(data[11:19], []byte(fmt.Sprintf("%-8s", t.Stock)))
+	return t.appendBinary(dst), nil
+}
 
-	data[19] = byte(t.TradingState)
-	data[20] = t.Reserved
+func (t StockTradingAction) MarshalJSON() ([]byte, error) {
+	type message StockTradingAction
+	return marshalMessageJSON(MESSAGE_STOCK_TRADING_ACTION, message(t))
+}
+
+func (t *StockTradingAction) UnmarshalJSON(data []byte) error {
+	type message StockTradingAction
+	return unmarshalMessageJSON(data, MESSAGE_STOCK_TRADING_ACTION, (*message)(t))
+}
 
-	copy(data[21:25], []byte(fmt.Sprintf("%-4s", t.Reason)))
+func (t StockTradingAction) appendBinary(dst []byte) []byte {
+	dst = appendHeader(dst, MESSAGE_STOCK_TRADING_ACTION, t.StockLocate, t.TrackingNumber, t.Timestamp)
+	dst = appendAlpha(dst, t.Stock, 8)
+	dst = append(dst, byte(t.TradingState), t.Reserved)
+	dst = appendAlpha(dst, t.Reason, 4)
 
-	return data
+	return dst
+}
+
+func (t StockTradingAction) validate() error {
+	v := validator{messageType: MESSAGE_STOCK_TRADING_ACTION}
+	v.timestamp("Timestamp", t.Timestamp)
+	v.alpha("Stock", t.Stock, 8)
+	v.enum("TradingState", t.TradingState.valid(), uint8(t.TradingState))
+	v.alpha("Reason", t.Reason, 4)
+
+	return v.err
 }
 
 func ParseStockTradingAction(data []byte) (StockTradingAction, error) {
+	var t StockTradingAction
+	err := t.UnmarshalBinary(data)
+	return t, err
+}
+
+func (t *StockTradingAction) UnmarshalBinary(data []byte) error {
+	return t.decode(data, nil)
+}
+
+func (t *StockTradingAction) decode(data []byte, symbols symbolTable) error {
 	if len(data) != stockTradingActionSize {
-		return StockTradingAction{}, NewInvalidPacketSize(stockTradingActionSize, len(data))
+		return NewInvalidPacketSize(stockTradingActionSize, len(data))
 	}
 
 	locate := binary.BigEndian.Uint16(data[1:3])
 	tracking := binary.BigEndian.Uint16(data[3:5])
-	data[3] = 0
-	data[4] = 0
-	t := binary.BigEndian.Uint64(data[3:11])
+	timestamp := parseTimestamp(data[5:11])
 
-	return StockTradingAction{
+	*t = StockTradingAction{
 		StockLocate:    locate,
 		TrackingNumber: tracking,
-		Timestamp:      time.Duration(t),
-		Stock:          strings.TrimSpace(string(data[11:19])),
+		Timestamp:      timestamp,
+		Stock:          symbols.get(data[11:19]),
 		TradingState:   TradingState(data[19]),
 		Reserved:       data[20],
-		Reason:         strings.TrimSpace(string(data[21:25])),
-	}, nil
+		Reason:         symbols.get(data[21:25]),
+	}
+
+	return nil
 }
 
 func (a StockTradingAction) String() string {
@@ -104,3 +146,20 @@ func (t TradingState) String() string {
 
 	return "Unknown Trading State"
 }
+
+func (t TradingState) valid() bool {
+	switch t {
+	case STATE_HALTED, STATE_PAUSED, STATE_QUOTATION, STATE_TRADING:
+		return true
+	}
+
+	return false
+}
+
+func (t TradingState) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(string(rune(t)), t.String())
+}
+
+func (t *TradingState) UnmarshalJSON(data []byte) error {
+	return unmarshalCharEnumJSON(data, (*uint8)(t))
+}
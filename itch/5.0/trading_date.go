@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// newYork loads America/New_York from the system time zone database. Programs that run on systems without
+// one can embed it by importing time/tzdata in their main package
+var newYork = sync.OnceValues(func() (*time.Location, error) {
+	return time.LoadLocation("America/New_York")
+})
+
+// TradingDate is the date of an ITCH feed. Message timestamps are nanoseconds since midnight Eastern time
+// on the trading date, so the date is needed to convert them to a time.Time
+type TradingDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// NewTradingDate returns the trading date of t in its own location
+func NewTradingDate(t time.Time) TradingDate {
+	year, month, day := t.Date()
+	return TradingDate{Year: year, Month: month, Day: day}
+}
+
+// TradingDateFromFilename infers the trading date from the name of a file from the NASDAQ FTP server, such
+// as 01302020.NASDAQ_ITCH50 or 01302020.NASDAQ_ITCH50.gz, which start with the date as MMDDYYYY
+func TradingDateFromFilename(path string) (TradingDate, bool) {
+	name := filepath.Base(path)
+	if len(name) < 8 || (len(name) > 8 && name[8] != '.') {
+		return TradingDate{}, false
+	}
+
+	t, err := time.Parse("01022006", name[:8])
+	if err != nil {
+		return TradingDate{}, false
+	}
+
+	return NewTradingDate(t), true
+}
+
+// IsZero reports whether the trading date is unset
+func (d TradingDate) IsZero() bool {
+	return d == TradingDate{}
+}
+
+// Midnight returns the start of the trading date in America/New_York. It returns an error if the time zone
+// can't be loaded, see time.LoadLocation
+func (d TradingDate) Midnight() (time.Time, error) {
+	location, err := newYork()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, location), nil
+}
+
+// Time converts a message timestamp to a time.Time in America/New_York
+func (d TradingDate) Time(timestamp time.Duration) (time.Time, error) {
+	midnight, err := d.Midnight()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return midnight.Add(timestamp), nil
+}
+
+// MessageTime returns when a message was sent in America/New_York
+func (d TradingDate) MessageTime(msg ItchMessage) (time.Time, error) {
+	return d.Time(msg.Header().Timestamp)
+}
+
+// MessageTimeUTC returns when a message was sent in UTC
+func (d TradingDate) MessageTimeUTC(msg ItchMessage) (time.Time, error) {
+	t, err := d.MessageTime(msg)
+	return t.UTC(), err
+}
+
+func (d TradingDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// withTradingDate sets Configuration.TradingDate from the filename if it is not already set
+func withTradingDate(config Configuration, path string) Configuration {
+	if config.TradingDate.IsZero() {
+		if date, ok := TradingDateFromFilename(path); ok {
+			config.TradingDate = date
+		}
+	}
+
+	return config
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	// The tests should not depend on the time zone database of the system they run on
+	_ "time/tzdata"
+)
+
+func TestTradingDateFromFilename(t *testing.T) {
+	tests := []struct {
+		path   string
+		want   TradingDate
+		wantOk bool
+	}{
+		{"01302020.NASDAQ_ITCH50", TradingDate{2020, time.January, 30}, true},
+		{"/data/itch/07012019.NASDAQ_ITCH50.gz", TradingDate{2019, time.July, 1}, true},
+		{"12302019", TradingDate{2019, time.December, 30}, true},
+		{"13302020.NASDAQ_ITCH50", TradingDate{}, false},
+		{"013020201.NASDAQ_ITCH50", TradingDate{}, false},
+		{"sample.NASDAQ_ITCH50", TradingDate{}, false},
+		{"", TradingDate{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := TradingDateFromFilename(tt.path)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("TradingDateFromFilename() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTradingDate_MessageTime(t *testing.T) {
+	open := OrderDelete{Timestamp: 9*time.Hour + 30*time.Minute}
+
+	tests := []struct {
+		name    string
+		date    TradingDate
+		wantUTC time.Time
+	}{
+		{"standard time", TradingDate{2020, time.January, 30}, time.Date(2020, time.January, 30, 14, 30, 0, 0, time.UTC)},
+		{"daylight saving time", TradingDate{2020, time.July, 1}, time.Date(2020, time.July, 1, 13, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.date.MessageTime(open)
+			if err != nil {
+				t.Fatalf("MessageTime() error = %v", err)
+			}
+			if !got.Equal(tt.wantUTC) || got.Hour() != 9 || got.Minute() != 30 || got.Location().String() != "America/New_York" {
+				t.Errorf("MessageTime() = %v, want 09:30 America/New_York", got)
+			}
+
+			if got, err := tt.date.MessageTimeUTC(open); err != nil || got != tt.wantUTC {
+				t.Errorf("MessageTimeUTC() = %v, %v, want %v", got, err, tt.wantUTC)
+			}
+		})
+	}
+}
+
+func TestDecoder_TradingDate(t *testing.T) {
+	path := writeTestFile(t, testMessages())
+	inferred := TradingDate{2020, time.January, 30}
+	configured := TradingDate{2021, time.March, 1}
+
+	tests := []struct {
+		name   string
+		config Configuration
+		want   TradingDate
+	}{
+		{"inferred", Configuration{LengthFieldPrefixed: true}, inferred},
+		{"configured", Configuration{LengthFieldPrefixed: true, TradingDate: configured}, configured},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, open := range []func(string, Configuration) (*Decoder, error){OpenFile, OpenMapped} {
+				d, err := open(path, tt.config)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got := d.TradingDate(); got != tt.want {
+					t.Errorf("TradingDate() = %v, want %v", got, tt.want)
+				}
+				d.Close()
+			}
+		})
+	}
+
+	if got := NewDecoder(bytes.NewReader(nil), Configuration{}).TradingDate(); !got.IsZero() {
+		t.Errorf("TradingDate() = %v, want zero", got)
+	}
+}
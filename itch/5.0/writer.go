@@ -0,0 +1,175 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Writer writes ITCH messages to an io.Writer in the same format read by ParseFile and Decoder
+type Writer struct {
+	writer *bufio.Writer
+	gzip   *gzip.Writer
+	file   *os.File
+
+	// scratch is reused to encode each message
+	scratch []byte
+
+	lengthFieldPrefixed bool
+	compress            bool
+	compressionLevel    int
+	bufferSize          int
+}
+
+type WriterOption func(w *Writer)
+
+// WithLengthFieldPrefixed prefixes every message with a two byte length field, like the files on the NASDAQ
+// FTP server. It matches Configuration.LengthFieldPrefixed
+func WithLengthFieldPrefixed() WriterOption {
+	return func(w *Writer) {
+		w.lengthFieldPrefixed = true
+	}
+}
+
+// WithGzip compresses the output with gzip at the given level, for example gzip.DefaultCompression
+func WithGzip(level int) WriterOption {
+	return func(w *Writer) {
+		w.compress = true
+		w.compressionLevel = level
+	}
+}
+
+// WithWriteBufferSize sets the size of the write buffer. The default is 64KB
+func WithWriteBufferSize(size int) WriterOption {
+	return func(w *Writer) {
+		w.bufferSize = size
+	}
+}
+
+// NewWriter creates a Writer writing to writer. The Writer must be closed to flush any buffered messages,
+// which does not close writer itself
+func NewWriter(writer io.Writer, opts ...WriterOption) (*Writer, error) {
+	w := &Writer{
+		bufferSize: 1 << 16,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.compress {
+		gz, err := gzip.NewWriterLevel(writer, w.compressionLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		w.gzip = gz
+		writer = gz
+	}
+
+	w.writer = bufio.NewWriterSize(writer, w.bufferSize)
+
+	return w, nil
+}
+
+// CreateFile creates or truncates the named file and returns a Writer writing to it. Closing the Writer closes the file
+func CreateFile(path string, opts ...WriterOption) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := NewWriter(file, opts...)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	w.file = file
+
+	return w, nil
+}
+
+// binaryAppender is implemented by every message type. AppendBinary validates the message before encoding
+// it, returning an ErrInvalidField for the first field that cannot be encoded, and does not allocate if dst
+// has enough capacity. It matches encoding.BinaryAppender, which needs a newer Go than this module
+type binaryAppender interface {
+	AppendBinary(dst []byte) ([]byte, error)
+}
+
+// WriteMessage validates, encodes and writes a single message. The message must implement AppendBinary like
+// the message types of this package, otherwise an ErrUnsupportedMessage is returned
+func (w *Writer) WriteMessage(msg ItchMessage) error {
+	if msg == nil {
+		return NewInvalidPacketType(0)
+	}
+
+	appender, ok := msg.(binaryAppender)
+	if !ok {
+		return NewUnsupportedMessage(msg)
+	}
+
+	data, err := appender.AppendBinary(w.scratch[:0])
+	if err != nil {
+		return err
+	}
+	w.scratch = data
+
+	return w.WriteRaw(data)
+}
+
+// WriteRaw writes a single message that is already encoded, such as one read from another file
+func (w *Writer) WriteRaw(data []byte) error {
+	if len(data) == 0 || len(data) > math.MaxUint16 {
+		return fmt.Errorf("invalid message length=%d", len(data))
+	}
+
+	if w.lengthFieldPrefixed {
+		if err := w.writer.WriteByte(byte(len(data) >> 8)); err != nil {
+			return err
+		}
+		if err := w.writer.WriteByte(byte(len(data))); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.writer.Write(data)
+	return err
+}
+
+// Flush writes any buffered messages to the underlying io.Writer, including any pending compressed data
+func (w *Writer) Flush() error {
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+
+	if w.gzip != nil {
+		return w.gzip.Flush()
+	}
+
+	return nil
+}
+
+// Close flushes any buffered messages and finishes the gzip stream. If the Writer was created with
+// CreateFile the file is closed
+func (w *Writer) Close() error {
+	err := w.writer.Flush()
+
+	if w.gzip != nil {
+		err = errors.Join(err, w.gzip.Close())
+	}
+
+	if w.file != nil {
+		err = errors.Join(err, w.file.Close())
+		w.file = nil
+	}
+
+	return err
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2022 Mark Edward Winter
+ */
+
+package itch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []WriterOption
+		config Configuration
+	}{
+		{"raw", nil, Configuration{}},
+		{"length prefixed", []WriterOption{WithLengthFieldPrefixed()}, Configuration{LengthFieldPrefixed: true}},
+		{"gzip", []WriterOption{WithLengthFieldPrefixed(), WithGzip(gzip.BestSpeed)}, Configuration{LengthFieldPrefixed: true}},
+		{"small buffer", []WriterOption{WithWriteBufferSize(16)}, Configuration{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "01302020.NASDAQ_ITCH50")
+
+			w, err := CreateFile(path, tt.opts...)
+			if err != nil {
+				t.Fatalf("CreateFile() error = %v", err)
+			}
+			for _, m := range allMessages() {
+				if err := w.WriteMessage(m); err != nil {
+					t.Fatalf("WriteMessage() error = %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			got, err := ParseFile(path, tt.config)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+
+			if !cmp.Equal(got, allMessages()) {
+				t.Errorf("%v", cmp.Diff(allMessages(), got))
+			}
+		})
+	}
+}
+
+// externalMessage is an ItchMessage defined outside this package that has no AppendBinary method
+type externalMessage struct{}
+
+func (externalMessage) Bytes() []byte  { return []byte{'Z'} }
+func (externalMessage) Type() uint8    { return 'Z' }
+func (externalMessage) Header() Header { return Header{Type: 'Z'} }
+
+func TestWriter_UnsupportedMessage(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+
+	if err := w.WriteMessage(externalMessage{}); !errors.As(err, &ErrUnsupportedMessage{}) {
+		t.Errorf("WriteMessage() error = %v, want ErrUnsupportedMessage", err)
+	}
+
+	order := testMessages()[1].(OrderAdd)
+	if err := w.WriteMessage(&order); err != nil {
+		t.Errorf("WriteMessage(*OrderAdd) error = %v", err)
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	// Copying a file message by message produces an identical file
+	want := encodeMessages(allMessages(), true)
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, WithLengthFieldPrefixed())
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+
+	messages, err := ParseMany(want, Configuration{LengthFieldPrefixed: true})
+	if err != nil {
+		t.Fatalf("ParseMany() error = %v", err)
+	}
+	for _, m := range messages {
+		if err := w.WriteMessage(m); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("Writer wrote %v, want %v", buf.Bytes(), want)
+	}
+}
+
+func TestWriter_WriteRaw(t *testing.T) {
+	// Filter a file by copying the raw data of the wanted messages
+	path := filepath.Join(t.TempDir(), "01302020.NASDAQ_ITCH50")
+	if err := os.WriteFile(path, encodeMessages(testMessages(), true), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := OpenFile(path, Configuration{LengthFieldPrefixed: true, MessageTypes: []byte{MESSAGE_SYSTEM_EVENT}})
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	defer d.Close()
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	for {
+		data, err := d.readFrame()
+		if err != nil {
+			break
+		}
+		if err := w.WriteRaw(data); err != nil {
+			t.Fatalf("WriteRaw() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := []ItchMessage{testMessages()[0], testMessages()[4]}
+	if !bytes.Equal(buf.Bytes(), encodeMessages(want, false)) {
+		t.Errorf("Writer wrote %v, want %v", buf.Bytes(), encodeMessages(want, false))
+	}
+
+	if err := w.WriteRaw(nil); err == nil {
+		t.Errorf("WriteRaw(nil) expected error")
+	}
+	if err := w.WriteMessage(nil); err == nil {
+		t.Errorf("WriteMessage(nil) expected error")
+	}
+}
+
+func TestNewWriter_InvalidLevel(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, WithGzip(42)); err == nil {
+		t.Errorf("NewWriter() expected error")
+	}
+}